@@ -0,0 +1,128 @@
+package kick
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultMaxDeletionPercent denotes the default limit on the share of tracked files deleted by a single commit.
+const DefaultMaxDeletionPercent = 50
+
+// DeletionPercentFloor denotes the number of deletions a commit may make regardless of MaxDeletionPercent,
+// so that small repositories may delete a file or two freely.
+const DeletionPercentFloor = 2
+
+// MassChangeError reports staged changes too sweeping to commit unattended,
+// such as the deletions following an unmounted disk.
+type MassChangeError struct {
+	// Reason explains which limit tripped.
+	Reason string
+
+	// Deletions counts staged deletions.
+	Deletions int
+
+	// Changes counts staged paths.
+	Changes int
+
+	// Tracked counts files tracked before the commit.
+	Tracked int
+}
+
+// Error renders the tripped limit.
+func (o MassChangeError) Error() string {
+	return fmt.Sprintf(
+		"refusing to commit mass change: %s (%d deletion(s), %d changed path(s), %d tracked file(s))",
+		o.Reason,
+		o.Deletions,
+		o.Changes,
+		o.Tracked,
+	)
+}
+
+// validateMassChangeLimits rejects negative or out of range limits.
+func validateMassChangeLimits(maxDeletions int, maxDeletionPercent float64, maxChanges int) error {
+	if maxDeletions < 0 {
+		return fmt.Errorf("invalid deletion limit %d, expected zero or more", maxDeletions)
+	}
+
+	if maxDeletionPercent < 0 || maxDeletionPercent > 100 {
+		return fmt.Errorf("invalid deletion percentage limit %v, expected 0 through 100", maxDeletionPercent)
+	}
+
+	if maxChanges < 0 {
+		return fmt.Errorf("invalid change limit %d, expected zero or more", maxChanges)
+	}
+
+	return nil
+}
+
+// trackedCount counts the files tracked by HEAD, yielding zero for unborn branches.
+func (o Config) trackedCount() (int, error) {
+	if o.head() == "" {
+		return 0, nil
+	}
+
+	result, err := o.git(StepMassChange, "ls-tree", "-r", "-z", "--name-only", "HEAD")
+
+	if err != nil {
+		return 0, err
+	}
+
+	return strings.Count(string(result.Stdout), "\x00"), nil
+}
+
+// massChangeLimited reports whether any mass change limit applies.
+func (o Config) massChangeLimited() bool {
+	return !o.AllowMassChange && (o.MaxDeletions != 0 || o.MaxDeletionPercent != 0 || o.MaxChanges != 0)
+}
+
+// massChangeLimit refuses counts of deletions and changed paths exceeding MaxDeletions, MaxDeletionPercent, or MaxChanges,
+// given the number of tracked files.
+func (o Config) massChangeLimit(deletions int, changes int, tracked int) error {
+	massChangeErr := MassChangeError{Deletions: deletions, Changes: changes, Tracked: tracked}
+
+	switch {
+	case o.MaxDeletions > 0 && deletions > o.MaxDeletions:
+		massChangeErr.Reason = fmt.Sprintf("deletes more than %d files", o.MaxDeletions)
+	case o.MaxDeletionPercent > 0 &&
+		deletions > DeletionPercentFloor &&
+		float64(deletions)*100/float64(tracked) > o.MaxDeletionPercent:
+		massChangeErr.Reason = fmt.Sprintf("deletes more than %v%% of tracked files", o.MaxDeletionPercent)
+	case o.MaxChanges > 0 && changes > o.MaxChanges:
+		massChangeErr.Reason = fmt.Sprintf("touches more than %d paths", o.MaxChanges)
+	default:
+		return nil
+	}
+
+	return massChangeErr
+}
+
+// CheckMassChange refuses staged changes exceeding MaxDeletions, MaxDeletionPercent, or MaxChanges,
+// unless AllowMassChange overrides the limits.
+func (o Config) CheckMassChange() error {
+	if !o.massChangeLimited() {
+		return nil
+	}
+
+	changes, err := o.stagedChanges()
+
+	if err != nil {
+		return err
+	}
+
+	var deletions int
+
+	for _, change := range changes {
+		if change.Index == 'D' {
+			deletions++
+		}
+	}
+
+	tracked, err := o.trackedCount()
+
+	if err != nil {
+		return err
+	}
+
+	return o.massChangeLimit(deletions, len(changes), tracked)
+}
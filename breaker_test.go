@@ -0,0 +1,87 @@
+package kick
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMassChangeLimit(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		deletions int
+		changes   int
+		tracked   int
+		refused   bool
+	}{
+		{"within limits", 5, 10, 100, false},
+		{"deletion percent", 60, 60, 100, true},
+		{"small repository", 1, 1, 1, false},
+		{"deletion floor", DeletionPercentFloor, DeletionPercentFloor, DeletionPercentFloor, false},
+		{"every file of a small repository", 5, 5, 5, true},
+		{"above deletion floor", 3, 3, 5, true},
+		{"deletion count", 11, 11, 1000, true},
+		{"change count", 0, 101, 1000, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			config := NewConfig()
+			config.MaxDeletions = 10
+			config.MaxChanges = 100
+			err := config.massChangeLimit(tc.deletions, tc.changes, tc.tracked)
+			var massChangeErr MassChangeError
+
+			if refused := errors.As(err, &massChangeErr); refused != tc.refused {
+				t.Errorf("got %v, expected refusal %v", err, tc.refused)
+			}
+		})
+	}
+}
+
+func TestMassChangeLimited(t *testing.T) {
+	config := NewConfig()
+
+	if !config.massChangeLimited() {
+		t.Error("expected default limits to apply")
+	}
+
+	config.AllowMassChange = true
+
+	if config.massChangeLimited() {
+		t.Error("expected AllowMassChange to lift limits")
+	}
+}
+
+func TestCheckMassChange(t *testing.T) {
+	for _, tc := range []struct {
+		name            string
+		deletions       int
+		tracked         int
+		allowMassChange bool
+		refused         bool
+	}{
+		{"few deletions", 2, 100, false, false},
+		{"most files deleted", 60, 100, false, true},
+		{"every file of a small repository", 15, 15, false, true},
+		{"override", 60, 100, true, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			config, runner := fakeConfig(
+				t,
+				fakeResponse{prefix: "diff --cached --name-status", stdout: strings.Repeat("D\x00gone.txt\x00", tc.deletions)},
+				fakeResponse{prefix: "rev-parse --verify --quiet HEAD", stdout: "head\n"},
+				fakeResponse{prefix: "ls-tree -r -z --name-only HEAD", stdout: strings.Repeat("file.txt\x00", tc.tracked)},
+			)
+			config.AllowMassChange = tc.allowMassChange
+			err := config.CheckMassChange()
+			var massChangeErr MassChangeError
+
+			if refused := errors.As(err, &massChangeErr); refused != tc.refused {
+				t.Errorf("got %v, expected refusal %v", err, tc.refused)
+			}
+
+			if tc.allowMassChange && runner.called("ls-tree") {
+				t.Errorf("unexpected queries with limits lifted, calls: %q", runner.calls)
+			}
+		})
+	}
+}
@@ -4,10 +4,13 @@ package kick
 import (
 	"bufio"
 	"bytes"
-	"io"
+	"errors"
+	"fmt"
 	"log"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 	"time"
 )
 
@@ -17,12 +20,20 @@ const DefaultCommitMessage = "up"
 // CommitMessageEnvironmentVariable denotes the name of the environment variable controlling commit messages.
 const CommitMessageEnvironmentVariable = "KICK_MESSAGE"
 
-// NoncePath denotes the file path to a nonce file, relative to the current working directory.
+// NoncePath denotes the default nonce path, relative to the repository directory.
 const NoncePath = ".kick"
 
+// NonceHostPath denotes the default per-host nonce directory in NonceModeHost, relative to the repository directory.
+//
+// Differs from NoncePath, so that repositories already holding a NoncePath file can switch modes.
+const NonceHostPath = ".kick.d"
+
 // NonceEnvironmentVariable denotes the name of the environment variable controlling nonces.
 const NonceEnvironmentVariable = "KICK_NONCE"
 
+// NonceModeEnvironmentVariable denotes the name of the environment variable controlling nonce modes.
+const NonceModeEnvironmentVariable = "KICK_NONCE_MODE"
+
 // FetchAllEnvironmentVariable denotes the name of the environment variable controlling whether fetches process all remotes.
 const FetchAllEnvironmentVariable = "KICK_FETCH_ALL"
 
@@ -35,62 +46,400 @@ const PushAllEnvironmentVariable = "KICK_PUSH_ALL"
 // SyncTagsEnvironmentVariable denotes the name of the environment variable controlling whether to push and pull tags.
 const SyncTagsEnvironmentVariable = "KICK_SYNC_TAGS"
 
+// PullStrategyEnvironmentVariable denotes the name of the environment variable controlling pull strategies.
+const PullStrategyEnvironmentVariable = "KICK_PULL_STRATEGY"
+
+// AutostashEnvironmentVariable denotes the name of the environment variable controlling whether pulls stash local changes.
+const AutostashEnvironmentVariable = "KICK_AUTOSTASH"
+
+// ConflictPolicyEnvironmentVariable denotes the name of the environment variable controlling conflict policies.
+const ConflictPolicyEnvironmentVariable = "KICK_CONFLICT_POLICY"
+
+// ConflictWinnerEnvironmentVariable denotes the name of the environment variable controlling which side of a conflict stays in place.
+const ConflictWinnerEnvironmentVariable = "KICK_CONFLICT_WINNER"
+
+// SkipChecksEnvironmentVariable denotes the name of the environment variable listing preflight checks to skip, comma separated.
+const SkipChecksEnvironmentVariable = "KICK_SKIP_CHECKS"
+
+// SetUpstreamEnvironmentVariable denotes the name of the environment variable controlling whether pushes link new branches to upstream branches.
+const SetUpstreamEnvironmentVariable = "KICK_SET_UPSTREAM"
+
+// UpstreamRemoteEnvironmentVariable denotes the name of the environment variable selecting the remote that new branches link to.
+const UpstreamRemoteEnvironmentVariable = "KICK_UPSTREAM_REMOTE"
+
+// MessageModeEnvironmentVariable denotes the name of the environment variable controlling message modes.
+const MessageModeEnvironmentVariable = "KICK_MESSAGE_MODE"
+
+// SubjectLimitEnvironmentVariable denotes the name of the environment variable controlling the maximum length of generated commit subjects.
+const SubjectLimitEnvironmentVariable = "KICK_SUBJECT_LIMIT"
+
+// MessageFileEnvironmentVariable denotes the name of the environment variable naming the commit message file.
+const MessageFileEnvironmentVariable = "KICK_MESSAGE_FILE"
+
+// MessageCommandEnvironmentVariable denotes the name of the environment variable naming the commit message command.
+const MessageCommandEnvironmentVariable = "KICK_MESSAGE_COMMAND"
+
+// IncludeEnvironmentVariable denotes the name of the environment variable listing globs that staged paths must match, comma separated.
+const IncludeEnvironmentVariable = "KICK_INCLUDE"
+
+// ExcludeEnvironmentVariable denotes the name of the environment variable listing globs that staged paths must not match, comma separated.
+const ExcludeEnvironmentVariable = "KICK_EXCLUDE"
+
+// ScanSecretsEnvironmentVariable denotes the name of the environment variable controlling whether to scan staged changes for secrets.
+const ScanSecretsEnvironmentVariable = "KICK_SCAN_SECRETS"
+
+// MaxFileSizeEnvironmentVariable denotes the name of the environment variable controlling the size limit per staged file.
+const MaxFileSizeEnvironmentVariable = "KICK_MAX_FILE_SIZE"
+
+// MaxTotalSizeEnvironmentVariable denotes the name of the environment variable controlling the total size limit of staged files.
+const MaxTotalSizeEnvironmentVariable = "KICK_MAX_TOTAL_SIZE"
+
+// BlockBinaryEnvironmentVariable denotes the name of the environment variable controlling whether to reject staged binary files.
+const BlockBinaryEnvironmentVariable = "KICK_BLOCK_BINARY"
+
+// SizePolicyEnvironmentVariable denotes the name of the environment variable controlling size policies.
+const SizePolicyEnvironmentVariable = "KICK_SIZE_POLICY"
+
+// MaxDeletionsEnvironmentVariable denotes the name of the environment variable limiting staged deletions.
+const MaxDeletionsEnvironmentVariable = "KICK_MAX_DELETIONS"
+
+// MaxDeletionPercentEnvironmentVariable denotes the name of the environment variable limiting the share of tracked files deleted.
+const MaxDeletionPercentEnvironmentVariable = "KICK_MAX_DELETION_PERCENT"
+
+// MaxChangesEnvironmentVariable denotes the name of the environment variable limiting staged paths.
+const MaxChangesEnvironmentVariable = "KICK_MAX_CHANGES"
+
 // Config prepares high level git sync operations.
+//
+// Fields load from TOML configuration files by their snake_case keys.
 type Config struct {
 	// Debug enables additional logging (default: false).
-	Debug bool
+	Debug bool `toml:"debug"`
+
+	// Nonce enables generating commits when repositories are otherwise unchanged (default: false).
+	Nonce bool `toml:"nonce"`
+
+	// NonceMode selects how nonces generate commits,
+	// one of NonceModes (default: NonceModeFile).
+	NonceMode string `toml:"nonce_mode"`
 
-	// Nonce enables altering NoncePath to generate commits when repositories are otherwise unchanged (default: false).
-	Nonce bool
+	// NoncePath denotes the nonce file, or the per-host nonce directory in NonceModeHost,
+	// relative to the repository directory, which it may not escape
+	// (default: NoncePath, or NonceHostPath in NonceModeHost).
+	NoncePath string `toml:"nonce_path"`
+
+	// NonceFormat denotes the Go time layout of nonce timestamps (default: DefaultNonceFormat).
+	NonceFormat string `toml:"nonce_format"`
 
 	// FetchAll enables fetching from all remotes (default: true).
-	FetchAll bool
+	FetchAll bool `toml:"fetch_all"`
 
 	// PullAll enables pulling from all remotes (default: true).
-	PullAll bool
+	PullAll bool `toml:"pull_all"`
 
 	// PushAll enables pushing to all remotes (default: true).
-	PushAll bool
+	PushAll bool `toml:"push_all"`
 
 	// SyncTags enables pushing and pulling tags (default: true).
-	SyncTags bool
+	SyncTags bool `toml:"sync_tags"`
+
+	// SetUpstream enables pushing branches lacking an upstream branch with --set-upstream,
+	// instead of failing (default: false).
+	SetUpstream bool `toml:"set_upstream"`
+
+	// UpstreamRemote names the remote that SetUpstream links branches to
+	// (default: the remote git pushes the current branch to).
+	UpstreamRemote string `toml:"upstream_remote"`
+
+	// Include limits staging to paths matching any of these globs, per MatchPath (default: all paths).
+	Include []string `toml:"include"`
+
+	// Exclude prevents staging paths matching any of these globs, per MatchPath,
+	// in addition to any patterns in KickignoreFilename (default: none).
+	Exclude []string `toml:"exclude"`
+
+	// PullStrategy selects how pulls reconcile divergent branches,
+	// one of PullStrategies (default: PullStrategyMerge).
+	PullStrategy string `toml:"pull_strategy"`
+
+	// Autostash enables stashing local changes around pulls (default: false).
+	Autostash bool `toml:"autostash"`
+
+	// ConflictPolicy selects how Pull handles conflicts,
+	// one of ConflictPolicies (default: ConflictPolicyAbort).
+	ConflictPolicy string `toml:"conflict_policy"`
+
+	// ConflictWinner selects which side of a conflict stays in place under ConflictPolicyCopy,
+	// one of ConflictWinners (default: ConflictWinnerRemote).
+	ConflictWinner string `toml:"conflict_winner"`
 
-	// CommitMessage denotes a git commit message (default: DefaultCommitMessage).
-	CommitMessage string
+	// ConflictCopyPaths limits ConflictPolicyCopy to paths matching any of these globs (default: all paths).
+	ConflictCopyPaths []string `toml:"conflict_copy_paths"`
+
+	// MergeRules resolve conflicts in matching paths automatically during pulls,
+	// with the first matching rule taking effect (default: none).
+	MergeRules []MergeRule `toml:"merge_rules"`
+
+	// ScanSecrets enables refusing to commit staged changes containing suspected secrets (default: true).
+	ScanSecrets bool `toml:"scan_secrets"`
+
+	// MaxFileSize limits the size of each staged file, with zero disabling the limit (default: DefaultMaxFileSize).
+	MaxFileSize ByteSize `toml:"max_file_size"`
+
+	// MaxTotalSize limits the total size of staged files, with zero disabling the limit (default: 0).
+	MaxTotalSize ByteSize `toml:"max_total_size"`
+
+	// BlockBinary enables rejecting staged binary files (default: false).
+	BlockBinary bool `toml:"block_binary"`
+
+	// SizePolicy selects how staged files breaking MaxFileSize, MaxTotalSize, or BlockBinary are handled,
+	// one of SizePolicies (default: SizePolicyAbort).
+	SizePolicy string `toml:"size_policy"`
+
+	// MaxDeletions limits the files deleted by a single commit, with zero disabling the limit (default: 0).
+	MaxDeletions int `toml:"max_deletions"`
+
+	// MaxDeletionPercent limits the percentage of tracked files deleted by a single commit,
+	// with zero disabling the limit (default: DefaultMaxDeletionPercent).
+	MaxDeletionPercent float64 `toml:"max_deletion_percent"`
+
+	// MaxChanges limits the paths touched by a single commit, with zero disabling the limit (default: 0).
+	MaxChanges int `toml:"max_changes"`
+
+	// AllowMassChange overrides MaxDeletions, MaxDeletionPercent, and MaxChanges for a single run (default: false).
+	AllowMassChange bool `toml:"-"`
+
+	// SkipChecks lists PreflightChecks to skip (default: none).
+	SkipChecks []string `toml:"skip_checks"`
+
+	// CommitMessage denotes a git commit message, as a text/template evaluated against a MessageContext
+	// (default: DefaultCommitMessage).
+	CommitMessage string `toml:"commit_message"`
+
+	// MessageMode selects how commit messages are composed,
+	// one of MessageModes (default: MessageModeTemplate).
+	MessageMode string `toml:"message_mode"`
+
+	// SubjectLimit denotes the maximum length of commit subjects generated by MessageModeAuto
+	// (default: DefaultSubjectLimit).
+	SubjectLimit int `toml:"subject_limit"`
+
+	// MessageFile denotes the file read by MessageModeFile,
+	// relative to the repository directory, which it may not escape (default: none).
+	MessageFile string `toml:"message_file"`
+
+	// MessageCommand denotes the shell command run by MessageModeCommand (default: none).
+	MessageCommand string `toml:"message_command"`
+
+	// WatchDebounce denotes how long Watch waits for file changes to settle before kicking (default: DefaultWatchDebounce).
+	WatchDebounce time.Duration `toml:"watch_debounce"`
+
+	// WatchPullInterval denotes how often Watch pulls remote changes while idle (default: DefaultWatchPullInterval).
+	WatchPullInterval time.Duration `toml:"watch_pull_interval"`
+
+	// Hooks denotes shell commands run at points during Kick (default: none).
+	Hooks HookCommands `toml:"hooks"`
+
+	// Dir denotes a directory within the repository (default: the current working directory).
+	//
+	// Kick resolves Dir to the repository's top level.
+	Dir string `toml:"-"`
+
+	// Runner executes git commands (default: ExecGitRunner).
+	Runner GitRunner `toml:"-"`
 
 	// remotes tracks the repository's configured remote names.
 	remotes []string
+
+	// isolate shields hook and message commands from interrupt signals sent to kick.
+	isolate bool
+
+	// pullRange tracks the commits brought in by the latest pull, as old..new, for hooks.
+	pullRange string
 }
 
 // NewConfig constructs a Config.
 func NewConfig() Config {
 	return Config{
-		FetchAll:      true,
-		PullAll:       true,
-		PushAll:       true,
-		SyncTags:      true,
-		CommitMessage: DefaultCommitMessage,
+		NonceMode:          NonceModeFile,
+		NonceFormat:        DefaultNonceFormat,
+		FetchAll:           true,
+		PullAll:            true,
+		PushAll:            true,
+		SyncTags:           true,
+		ScanSecrets:        true,
+		MaxFileSize:        DefaultMaxFileSize,
+		SizePolicy:         SizePolicyAbort,
+		MaxDeletionPercent: DefaultMaxDeletionPercent,
+		PullStrategy:       PullStrategyMerge,
+		ConflictPolicy:     ConflictPolicyAbort,
+		ConflictWinner:     ConflictWinnerRemote,
+		CommitMessage:      DefaultCommitMessage,
+		MessageMode:        MessageModeTemplate,
+		SubjectLimit:       DefaultSubjectLimit,
+		WatchDebounce:      DefaultWatchDebounce,
+		WatchPullInterval:  DefaultWatchPullInterval,
 	}
 }
 
-// QueryRemotes populates metadata for remotes.
-func (o *Config) QueryRemotes() error {
-	cmd := exec.Command("git")
-	cmd.Args = append(cmd.Args, "remote")
-	cmd.Env = os.Environ()
-	cmd.Stderr = os.Stderr
+// Validate rejects unsupported settings with a UsageError.
+func (o Config) Validate() error {
+	if err := o.validate(); err != nil {
+		return UsageError{Err: err}
+	}
 
-	if o.Debug {
-		log.Printf("cmd: %v\n", cmd)
+	return nil
+}
+
+// validateRepositoryPath rejects paths that are absolute or escape the repository directory.
+func validateRepositoryPath(name string, pth string) error {
+	if pth != "" && !filepath.IsLocal(filepath.FromSlash(pth)) {
+		return fmt.Errorf("invalid %s %q, expected a path within the repository", name, pth)
 	}
 
-	remotesBytes, err := cmd.Output()
+	return nil
+}
+
+// validate rejects unsupported settings.
+func (o Config) validate() error {
+	if err := validateNonceMode(o.NonceMode); err != nil {
+		return err
+	}
+
+	if err := validateRepositoryPath("nonce path", o.NoncePath); err != nil {
+		return err
+	}
+
+	if err := validatePullStrategy(o.PullStrategy); err != nil {
+		return err
+	}
+
+	if err := validateConflictPolicy(o.ConflictPolicy); err != nil {
+		return err
+	}
+
+	if err := validateConflictWinner(o.ConflictWinner); err != nil {
+		return err
+	}
+
+	if err := validateMergeRules(o.MergeRules); err != nil {
+		return err
+	}
+
+	if err := validateCommitMessage(o.CommitMessage); err != nil {
+		return err
+	}
+
+	if err := validateMessageMode(o.MessageMode); err != nil {
+		return err
+	}
+
+	if err := validateSubjectLimit(o.SubjectLimit); err != nil {
+		return err
+	}
+
+	if err := o.validateMessageSource(); err != nil {
+		return err
+	}
+
+	if err := validateRepositoryPath("message file", o.MessageFile); err != nil {
+		return err
+	}
+
+	if err := validateSizePolicy(o.SizePolicy); err != nil {
+		return err
+	}
+
+	if err := validateMassChangeLimits(o.MaxDeletions, o.MaxDeletionPercent, o.MaxChanges); err != nil {
+		return err
+	}
+
+	return validateSkipChecks(o.SkipChecks)
+}
+
+// git executes a git command for the given step with the configured runner,
+// classifying any failure.
+func (o Config) git(step string, args ...string) (GitResult, error) {
+	return o.gitInput(step, nil, args...)
+}
+
+// gitInput executes a git command for the given step with the configured runner,
+// feeding standard input, and classifying any failure.
+func (o Config) gitInput(step string, stdin []byte, args ...string) (GitResult, error) {
+	runner := o.Runner
+
+	if runner == nil {
+		runner = ExecGitRunner{Debug: o.Debug}
+	}
+
+	result, err := runner.Run(GitCommand{Dir: o.Dir, Args: args, Stdin: stdin})
+
+	if err != nil {
+		output := string(result.Stdout) + string(result.Stderr)
+		return result, Classify(step, args, output, err)
+	}
+
+	return result, nil
+}
+
+// shell prepares a shell command line, such as a hook, in the repository directory.
+func (o Config) shell(command string) *exec.Cmd {
+	cmd := shellCommand(command)
+	cmd.Dir = o.Dir
+
+	if o.isolate {
+		cmd.SysProcAttr = isolatedProcAttr()
+		cmd.Env = isolatedEnviron(os.Environ())
+	}
+
+	return cmd
+}
+
+// inRepository applies f to the repository directory,
+// confining file access beneath it so that neither ".." nor symbolic links reach outside files.
+func (o Config) inRepository(f func(root *os.Root) error) error {
+	root, err := os.OpenRoot(o.Dir)
 
 	if err != nil {
 		return err
 	}
 
-	scanner := bufio.NewScanner(bytes.NewReader(remotesBytes))
+	defer func() {
+		if closeErr := root.Close(); closeErr != nil && o.Debug {
+			log.Println(closeErr)
+		}
+	}()
+
+	return f(root)
+}
+
+// ResolveDir points Dir at the repository's top level directory,
+// so that operations cover the whole repository rather than a subdirectory.
+func (o *Config) ResolveDir() error {
+	result, err := o.git(StepRepository, "rev-parse", "--show-toplevel")
+
+	if err != nil {
+		// Bare repositories lack a top level directory.
+		if bare, bareErr := o.queryFlag("--is-bare-repository"); bareErr == nil && bare {
+			return o.checkBare()
+		}
+
+		return err
+	}
+
+	o.Dir = strings.TrimSpace(string(result.Stdout))
+	return nil
+}
+
+// QueryRemotes populates metadata for remotes.
+func (o *Config) QueryRemotes() error {
+	result, err := o.git(StepRemotes, "remote")
+
+	if err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(result.Stdout))
 	o.remotes = o.remotes[:0]
 
 	for scanner.Scan() {
@@ -101,207 +450,297 @@ func (o *Config) QueryRemotes() error {
 	return nil
 }
 
-// EnsureNonce updates NoncePath with the current timestamp.
-func (o Config) EnsureNonce() error {
-	tRFC3339Bytes := []byte(time.Now().UTC().Format(time.RFC3339))
-	return os.WriteFile(NoncePath, tRFC3339Bytes, 0644)
+// stageArgs denotes git arguments for Stage.
+func (o Config) stageArgs() []string {
+	return []string{"add", "."}
 }
 
 // Stage stages any local file changes.
+//
+// With Include, Exclude, or KickignoreFilename patterns, stages only the selected paths,
+// unstaging any previously staged paths the patterns exclude.
 func (o Config) Stage() error {
-	cmd := exec.Command("git")
-	cmd.Args = append(cmd.Args, "add", ".")
-	cmd.Env = os.Environ()
-	cmd.Stdin = os.Stdin
+	rules, err := o.stageRules()
 
-	if o.Debug {
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-	} else {
-		cmd.Stdout = io.Discard
-		cmd.Stderr = io.Discard
+	if err != nil {
+		return err
 	}
 
-	if o.Debug {
-		log.Printf("cmd: %v\n", cmd)
+	if !rules.active() {
+		_, err = o.git(StepStage, o.stageArgs()...)
+		return err
 	}
 
-	return cmd.Run()
+	selected, unstage, err := o.stagePlan(rules)
+
+	if err != nil {
+		return err
+	}
+
+	if len(unstage) != 0 {
+		if _, err = o.gitInput(StepStage, pathspecInput(unstage), unstageArgs()...); err != nil {
+			return err
+		}
+
+		log.Printf("stage: unstaged excluded paths: %s\n", strings.Join(unstage, ", "))
+	}
+
+	if len(selected) == 0 {
+		return nil
+	}
+
+	_, err = o.gitInput(StepStage, pathspecInput(selected), selectiveStageArgs()...)
+	return err
 }
 
-// Commit commits any staged changes.
+// commitArgs denotes git arguments for Commit, given a rendered commit message.
+func (o Config) commitArgs(message string) []string {
+	args := []string{"commit"}
+
+	if o.Nonce && o.NonceMode == NonceModeEmpty {
+		args = append(args, "--allow-empty")
+	}
+
+	if message != "" {
+		args = append(args, "-m", message)
+	}
+
+	return args
+}
+
+// Commit commits any staged changes, composing a message per MessageMode.
+//
+// Reports NothingToCommitError when nothing is staged, such as when every change is excluded from staging,
+// unless NonceModeEmpty allows empty commits.
 func (o Config) Commit() error {
-	cmd := exec.Command("git")
-	cmd.Args = append(cmd.Args, "commit", "-a")
+	changes, err := o.stagedChanges()
+
+	if err != nil {
+		return err
+	}
 
-	if o.CommitMessage != "" {
-		cmd.Args = append(cmd.Args, "-m", o.CommitMessage)
+	if len(changes) == 0 && (!o.Nonce || o.NonceMode != NonceModeEmpty) {
+		return NothingToCommitError{GitError{
+			Step: StepCommit,
+			Args: []string{"diff", "--cached"},
+			Err:  errNothingStaged,
+		}}
 	}
 
-	cmd.Env = os.Environ()
-	cmd.Stdin = os.Stdin
+	message, err := o.commitMessage(changes)
 
-	if o.Debug {
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-	} else {
-		cmd.Stdout = io.Discard
-		cmd.Stderr = io.Discard
+	if err != nil {
+		return err
 	}
 
-	if o.Debug {
-		log.Printf("cmd: %v\n", cmd)
+	if _, err = o.git(StepCommit, o.commitArgs(message)...); err != nil {
+		return err
+	}
+
+	if o.MessageMode == MessageModeFile {
+		return o.clearMessageFile()
 	}
 
-	return cmd.Run()
+	return nil
 }
 
-// Pull pulls any remote changes.
-func (o Config) Pull() error {
-	cmd := exec.Command("git")
-	cmd.Args = append(cmd.Args, "pull")
+// pullArgs denotes git arguments for Pull.
+func (o Config) pullArgs() []string {
+	args := []string{"pull"}
+	args = append(args, pullStrategyArgs(o.PullStrategy)...)
+
+	if o.Autostash {
+		args = append(args, "--autostash")
+	}
 
 	if o.PullAll {
-		cmd.Args = append(cmd.Args, "--all")
+		args = append(args, "--all")
 	}
 
-	cmd.Env = os.Environ()
-	cmd.Stdin = os.Stdin
+	return args
+}
 
-	if o.Debug {
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-	} else {
-		cmd.Stdout = io.Discard
-		cmd.Stderr = io.Discard
+// Pull pulls any remote changes, applying ConflictPolicy to any conflicts.
+//
+// Skips branches that Push will link to an upstream branch, which have nothing to pull yet.
+func (o Config) Pull() error {
+	if _, _, ok := o.upstreamLink(); ok {
+		if o.Debug {
+			log.Println("pull: skipping branch without upstream")
+		}
+
+		return nil
 	}
 
-	if o.Debug {
-		log.Printf("cmd: %v\n", cmd)
+	head := o.head()
+	_, err := o.git(StepPull, o.pullArgs()...)
+	var mergeConflictErr MergeConflictError
+
+	if err == nil || !errors.As(err, &mergeConflictErr) {
+		return err
 	}
 
-	return cmd.Run()
+	return o.handlePullConflict(mergeConflictErr, head)
 }
 
-// Push pushes any local changes.
-func (o Config) Push() error {
-	cmd := exec.Command("git")
-	cmd.Args = append(cmd.Args, "push")
+// pushArgs denotes git arguments for Push.
+func (o Config) pushArgs() []string {
+	args := []string{"push"}
 
 	if o.PushAll {
-		cmd.Args = append(cmd.Args, "--all")
+		args = append(args, "--all")
 	}
 
-	cmd.Env = os.Environ()
-	cmd.Stdin = os.Stdin
+	return args
+}
 
-	if o.Debug {
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-	} else {
-		cmd.Stdout = io.Discard
-		cmd.Stderr = io.Discard
+// upstreamLink selects the remote and branch that SetUpstream would link the current branch to,
+// reporting false when the current branch needs no linking.
+func (o Config) upstreamLink() (string, string, bool) {
+	if !o.SetUpstream || o.hasUpstream() {
+		return "", "", false
 	}
 
-	if o.Debug {
-		log.Printf("cmd: %v\n", cmd)
+	branch := o.currentBranch()
+
+	if branch == "" {
+		return "", "", false
+	}
+
+	remote := o.UpstreamRemote
+
+	if remote == "" {
+		remote = o.pushRemote()
 	}
 
-	return cmd.Run()
+	return remote, branch, true
 }
 
-// FetchTags fetches any remote tags.
-func (o Config) FetchTags() error {
-	cmd := exec.Command("git")
-	cmd.Args = append(cmd.Args, "fetch", "--tags")
+// setUpstreamArgs denotes git arguments for pushing a branch and linking it to an upstream branch.
+func setUpstreamArgs(remote string, branch string) []string {
+	return []string{"push", "--set-upstream", remote, branch}
+}
 
-	if o.FetchAll {
-		cmd.Args = append(cmd.Args, "--all")
-	}
+// Push pushes any local changes.
+//
+// With SetUpstream, links a branch lacking an upstream branch to UpstreamRemote.
+func (o Config) Push() error {
+	if remote, branch, ok := o.upstreamLink(); ok {
+		if _, err := o.git(StepPush, setUpstreamArgs(remote, branch)...); err != nil {
+			return err
+		}
 
-	cmd.Env = os.Environ()
-	cmd.Stdin = os.Stdin
+		log.Printf("push: linked branch %s to %s/%s\n", branch, remote, branch)
 
-	if o.Debug {
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-	} else {
-		cmd.Stdout = io.Discard
-		cmd.Stderr = io.Discard
+		if !o.PushAll {
+			return nil
+		}
 	}
 
-	if o.Debug {
-		log.Printf("cmd: %v\n", cmd)
+	_, err := o.git(StepPush, o.pushArgs()...)
+	return err
+}
+
+// fetchTagsArgs denotes git arguments for FetchTags.
+func (o Config) fetchTagsArgs() []string {
+	args := []string{"fetch", "--tags"}
+
+	if o.FetchAll {
+		args = append(args, "--all")
 	}
 
-	return cmd.Run()
+	return args
 }
 
-// PushTags pushes any local tags.
-func (o Config) PushTags() error {
-	if o.PushAll {
-		for _, remote := range o.remotes {
-			cmd := exec.Command("git")
-			cmd.Args = append(cmd.Args, "push", remote, "--tags")
-			cmd.Env = os.Environ()
-			cmd.Stdin = os.Stdin
-
-			if o.Debug {
-				cmd.Stdout = os.Stdout
-				cmd.Stderr = os.Stderr
-			} else {
-				cmd.Stdout = io.Discard
-				cmd.Stderr = io.Discard
-			}
-
-			if o.Debug {
-				log.Printf("cmd: %v\n", cmd)
-			}
-
-			if err := cmd.Run(); err != nil {
-				return err
-			}
-		}
+// FetchTags fetches any remote tags.
+func (o Config) FetchTags() error {
+	_, err := o.git(StepFetchTags, o.fetchTagsArgs()...)
+	return err
+}
 
-		return nil
+// pushTagsArgs denotes git arguments for each invocation of PushTags.
+func (o Config) pushTagsArgs() [][]string {
+	if !o.PushAll {
+		return [][]string{{"push", "--tags"}}
 	}
 
-	cmd := exec.Command("git")
-	cmd.Args = append(cmd.Args, "push", "--tags")
-	cmd.Env = os.Environ()
-	cmd.Stdin = os.Stdin
+	var argss [][]string
 
-	if o.Debug {
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-	} else {
-		cmd.Stdout = io.Discard
-		cmd.Stderr = io.Discard
+	for _, remote := range o.remotes {
+		argss = append(argss, []string{"push", remote, "--tags"})
 	}
 
-	if o.Debug {
-		log.Printf("cmd: %v\n", cmd)
+	return argss
+}
+
+// PushTags pushes any local tags.
+func (o Config) PushTags() error {
+	for _, args := range o.pushTagsArgs() {
+		if _, err := o.git(StepPushTags, args...); err != nil {
+			return err
+		}
 	}
 
-	return cmd.Run()
+	return nil
 }
 
 // Kick automates:
 //
-// * Staging all file changes
-// * Committing all changes
-// * Pulling any remote changes
-// * Pushing any local changes
+// * Verifying that the repository is safe to sync
+// * Running the before_stage hook
+// * Staging file changes, per Include, Exclude, and KickignoreFilename
+// * Checking staged file sizes
+// * Checking staged changes for mass deletions and changes
+// * Scanning staged changes for secrets
+// * Running the before_commit hook
+// * Committing staged changes
+// * Pulling any remote changes, then running the after_pull hook
+// * Pushing any local changes, then running the after_push hook
 // * Pulling and pushing tags
+//
+// Kick runs the on_failure hook when any step fails, though not for invalid settings.
 func (o Config) Kick() error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+
+	err := o.kick()
+
+	if err != nil {
+		if hookErr := o.runHook(
+			HookOnFailure,
+			o.Hooks.OnFailure,
+			fmt.Sprintf("%s=%v", ErrorEnvironmentVariable, err),
+		); hookErr != nil {
+			log.Println(hookErr)
+		}
+	}
+
+	return err
+}
+
+// kick performs the steps of Kick.
+func (o *Config) kick() error {
 	if o.Debug {
 		log.Printf("config: %v\n", o)
 	}
 
+	if err := o.Preflight(); err != nil {
+		return err
+	}
+
+	if err := o.ResolveDir(); err != nil {
+		return err
+	}
+
 	if err := o.QueryRemotes(); err != nil {
 		return err
 	}
 
+	if err := o.runHook(HookBeforeStage, o.Hooks.BeforeStage); err != nil {
+		return err
+	}
+
 	if o.Nonce {
 		if err := o.EnsureNonce(); err != nil {
 			return err
@@ -312,13 +751,37 @@ func (o Config) Kick() error {
 		return err
 	}
 
+	if err := o.CheckSizes(); err != nil {
+		return err
+	}
+
+	if err := o.CheckMassChange(); err != nil {
+		return err
+	}
+
+	if o.ScanSecrets {
+		if err := o.CheckSecrets(); err != nil {
+			return err
+		}
+	}
+
+	if err := o.runHook(HookBeforeCommit, o.Hooks.BeforeCommit); err != nil {
+		return err
+	}
+
 	if err := o.Commit(); err != nil {
+		var nothingToCommitErr NothingToCommitError
+
+		if !errors.As(err, &nothingToCommitErr) {
+			return err
+		}
+
 		if o.Debug {
 			log.Println(err)
 		}
 	}
 
-	if err := o.Pull(); err != nil {
+	if err := o.pull(); err != nil {
 		return err
 	}
 
@@ -326,6 +789,8 @@ func (o Config) Kick() error {
 		return err
 	}
 
+	o.runAfterHook(HookAfterPush, o.Hooks.AfterPush)
+
 	if o.SyncTags {
 		if err := o.FetchTags(); err != nil {
 			return err
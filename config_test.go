@@ -0,0 +1,259 @@
+package kick
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fakeResponse answers git commands beginning with a prefix.
+type fakeResponse struct {
+	// prefix denotes the leading git arguments, joined with spaces.
+	prefix string
+
+	// stdout denotes standard output.
+	stdout string
+
+	// stderr denotes standard error.
+	stderr string
+
+	// err denotes any failure.
+	err error
+}
+
+// fakeRunner answers git commands with canned responses, recording each invocation.
+//
+// Commands matching no response succeed without output.
+type fakeRunner struct {
+	// responses lists canned responses, in order of precedence.
+	responses []fakeResponse
+
+	// calls records the arguments of each invocation, joined with spaces.
+	calls []string
+}
+
+// Run answers a git command with the first matching response.
+func (o *fakeRunner) Run(command GitCommand) (GitResult, error) {
+	line := strings.Join(command.Args, " ")
+	o.calls = append(o.calls, line)
+
+	for _, response := range o.responses {
+		if strings.HasPrefix(line, response.prefix) {
+			return GitResult{Stdout: []byte(response.stdout), Stderr: []byte(response.stderr)}, response.err
+		}
+	}
+
+	return GitResult{}, nil
+}
+
+// called reports whether any invocation began with a prefix.
+func (o fakeRunner) called(prefix string) bool {
+	return slices.ContainsFunc(o.calls, func(line string) bool { return strings.HasPrefix(line, prefix) })
+}
+
+// fakeConfig prepares a configuration driving a fake repository in a temporary directory,
+// skipping preflight checks.
+func fakeConfig(t *testing.T, responses ...fakeResponse) (Config, *fakeRunner) {
+	t.Helper()
+	dir := t.TempDir()
+	runner := &fakeRunner{responses: append([]fakeResponse{
+		{prefix: "rev-parse --show-toplevel", stdout: dir + "\n"},
+		{prefix: "remote", stdout: "origin\n"},
+	}, responses...)}
+
+	config := NewConfig()
+	config.Dir = dir
+	config.SkipChecks = PreflightChecks
+	config.Runner = runner
+	return config, runner
+}
+
+func TestKick(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		responses []fakeResponse
+		code      int
+		called    []string
+		notCalled []string
+	}{
+		{
+			name: "nothing staged",
+			code: ExitSuccess,
+			called: []string{
+				"add .",
+				"pull --no-rebase --ff --all",
+				"push --all",
+				"fetch --tags --all",
+				"push origin --tags",
+			},
+			notCalled: []string{"commit"},
+		},
+		{
+			name:      "staged changes",
+			responses: []fakeResponse{{prefix: "diff --cached --name-status", stdout: "M\x00README.md\x00"}},
+			code:      ExitSuccess,
+			called:    []string{"commit -m ", "pull --no-rebase --ff --all", "push --all"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			config, runner := fakeConfig(t, tc.responses...)
+
+			if code := ExitCode(config.Kick()); code != tc.code {
+				t.Errorf("exit code %d, expected %d, calls: %q", code, tc.code, runner.calls)
+			}
+
+			for _, prefix := range tc.called {
+				if !runner.called(prefix) {
+					t.Errorf("expected git %s, calls: %q", prefix, runner.calls)
+				}
+			}
+
+			for _, prefix := range tc.notCalled {
+				if runner.called(prefix) {
+					t.Errorf("unexpected git %s, calls: %q", prefix, runner.calls)
+				}
+			}
+		})
+	}
+}
+
+func TestCommitArgs(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		nonce     bool
+		nonceMode string
+		message   string
+		expected  []string
+	}{
+		{"message", false, NonceModeFile, "up", []string{"commit", "-m", "up"}},
+		{"editor", false, NonceModeFile, "", []string{"commit"}},
+		{"empty nonce", true, NonceModeEmpty, "up", []string{"commit", "--allow-empty", "-m", "up"}},
+		{"file nonce", true, NonceModeFile, "up", []string{"commit", "-m", "up"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			config := NewConfig()
+			config.Nonce = tc.nonce
+			config.NonceMode = tc.nonceMode
+
+			if args := config.commitArgs(tc.message); !slices.Equal(args, tc.expected) {
+				t.Errorf("got %q, expected %q", args, tc.expected)
+			}
+		})
+	}
+}
+
+func TestResolveDir(t *testing.T) {
+	config, _ := fakeConfig(t)
+	config.Dir = "/srv/repo/docs"
+	config.Runner = &fakeRunner{responses: []fakeResponse{{prefix: "rev-parse --show-toplevel", stdout: "/srv/repo\n"}}}
+
+	if err := config.ResolveDir(); err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Dir != "/srv/repo" {
+		t.Errorf("got %s, expected /srv/repo", config.Dir)
+	}
+}
+
+func TestResolveDirBare(t *testing.T) {
+	config, _ := fakeConfig(t)
+	config.Runner = &fakeRunner{responses: []fakeResponse{
+		{prefix: "rev-parse --show-toplevel", stderr: "fatal: this operation must be run in a work tree", err: errors.New("exit status 128")},
+		{prefix: "rev-parse --is-bare-repository", stdout: "true\n"},
+	}}
+	err := config.ResolveDir()
+	var preflightErr PreflightError
+
+	if !errors.As(err, &preflightErr) || preflightErr.Check != CheckBare {
+		t.Errorf("expected bare repository PreflightError, got %v", err)
+	}
+}
+
+func TestPushSetUpstream(t *testing.T) {
+	exitErr := errors.New("exit status 128")
+	noUpstream := fakeResponse{prefix: "rev-parse --abbrev-ref --symbolic-full-name @{upstream}", err: exitErr}
+	branch := fakeResponse{prefix: "symbolic-ref --quiet --short HEAD", stdout: "feature\n"}
+
+	for _, tc := range []struct {
+		name           string
+		responses      []fakeResponse
+		setUpstream    bool
+		upstreamRemote string
+		pushAll        bool
+		called         []string
+		notCalled      []string
+	}{
+		{
+			name:        "first push",
+			responses:   []fakeResponse{noUpstream, branch},
+			setUpstream: true,
+			called:      []string{"push --set-upstream origin feature"},
+			notCalled:   []string{"pull", "push --all"},
+		},
+		{
+			name:        "first push of every branch",
+			responses:   []fakeResponse{noUpstream, branch},
+			setUpstream: true,
+			pushAll:     true,
+			called:      []string{"push --set-upstream origin feature", "push --all"},
+			notCalled:   []string{"pull"},
+		},
+		{
+			name:           "upstream remote",
+			responses:      []fakeResponse{noUpstream, branch},
+			setUpstream:    true,
+			upstreamRemote: "backup",
+			called:         []string{"push --set-upstream backup feature"},
+		},
+		{
+			name:        "push remote",
+			responses:   []fakeResponse{noUpstream, branch, {prefix: "config --get remote.pushDefault", stdout: "mirror\n"}},
+			setUpstream: true,
+			called:      []string{"push --set-upstream mirror feature"},
+		},
+		{
+			name:        "existing upstream",
+			responses:   []fakeResponse{branch},
+			setUpstream: true,
+			called:      []string{"pull", "push"},
+			notCalled:   []string{"push --set-upstream"},
+		},
+		{
+			name:        "detached",
+			responses:   []fakeResponse{noUpstream},
+			setUpstream: true,
+			notCalled:   []string{"push --set-upstream"},
+		},
+		{
+			name:      "disabled",
+			responses: []fakeResponse{noUpstream, branch},
+			called:    []string{"pull", "push"},
+			notCalled: []string{"push --set-upstream"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			config, runner := fakeConfig(t, tc.responses...)
+			config.SetUpstream = tc.setUpstream
+			config.UpstreamRemote = tc.upstreamRemote
+			config.PushAll = tc.pushAll
+
+			if err := config.Kick(); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, prefix := range tc.called {
+				if !runner.called(prefix) {
+					t.Errorf("expected git %s, calls: %q", prefix, runner.calls)
+				}
+			}
+
+			for _, prefix := range tc.notCalled {
+				if runner.called(prefix) {
+					t.Errorf("unexpected git %s, calls: %q", prefix, runner.calls)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package kick
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+)
+
+// ConfigFilename denotes the basename of repository local configuration files.
+const ConfigFilename = ".kick.toml"
+
+// UserConfigPath resolves the user level configuration file,
+// under XDG_CONFIG_HOME (default: ~/.config).
+func UserConfigPath() (string, error) {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+
+	if configHome == "" {
+		home, err := os.UserHomeDir()
+
+		if err != nil {
+			return "", err
+		}
+
+		configHome = filepath.Join(home, ".config")
+	}
+
+	return filepath.Join(configHome, "kick", "config.toml"), nil
+}
+
+// RepositoryRestrictedKeys lists TOML keys rejected in ConfigFilename,
+// because they run shell commands, relax safety guards, or name files to read and write.
+//
+// Anyone able to push to a repository controls its ConfigFilename,
+// so these settings are only accepted from the user level file, -config, environment variables, and flags.
+var RepositoryRestrictedKeys = []string{
+	"block_binary",
+	"hooks",
+	"max_changes",
+	"max_deletion_percent",
+	"max_deletions",
+	"max_file_size",
+	"max_total_size",
+	"message_command",
+	"message_file",
+	"nonce_path",
+	"scan_secrets",
+	"size_policy",
+	"skip_checks",
+}
+
+// decodeFile merges settings from a TOML configuration file,
+// rejecting unknown keys and any of the given restricted keys with a UsageError.
+//
+// Leaves the configuration unchanged on error.
+func (o *Config) decodeFile(pth string, restricted []string) error {
+	decoded := *o
+	metadata, err := toml.DecodeFile(pth, &decoded)
+
+	if err != nil {
+		return UsageError{Err: err}
+	}
+
+	if undecoded := metadata.Undecoded(); len(undecoded) != 0 {
+		return UsageError{Err: fmt.Errorf("%s: unknown configuration keys: %v", pth, undecoded)}
+	}
+
+	for _, key := range restricted {
+		if metadata.IsDefined(key) {
+			return UsageError{Err: fmt.Errorf(
+				"%s: %s may not be set in repository local %s files, move it to the user level file, -config, environment variables, or flags",
+				pth,
+				key,
+				ConfigFilename,
+			)}
+		}
+	}
+
+	*o = decoded
+	return nil
+}
+
+// LoadFile merges settings from a TOML configuration file.
+//
+// Keys absent from the file retain their current values.
+func (o *Config) LoadFile(pth string) error {
+	return o.decodeFile(pth, nil)
+}
+
+// loadOptionalFile merges settings from a TOML configuration file, if present.
+func (o *Config) loadOptionalFile(pth string, restricted []string) error {
+	if _, err := os.Stat(pth); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	return o.decodeFile(pth, restricted)
+}
+
+// LoadFiles merges settings from any user level configuration file,
+// followed by any ConfigFilename in Dir, which may not set RepositoryRestrictedKeys.
+func (o *Config) LoadFiles() error {
+	userConfigPath, err := UserConfigPath()
+
+	if err != nil {
+		return err
+	}
+
+	if err = o.loadOptionalFile(userConfigPath, nil); err != nil {
+		return err
+	}
+
+	return o.loadOptionalFile(filepath.Join(o.Dir, ConfigFilename), RepositoryRestrictedKeys)
+}
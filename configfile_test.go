@@ -0,0 +1,91 @@
+package kick
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeConfigFile writes a repository local configuration file to a temporary repository directory.
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	dir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(dir, ConfigFilename), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return dir
+}
+
+func TestLoadFiles(t *testing.T) {
+	dir := writeConfigFile(t, "pull_strategy = \"rebase\"\ninclude = [\"docs/\"]\n")
+	config := NewConfig()
+	config.Dir = dir
+
+	if err := config.LoadFiles(); err != nil {
+		t.Fatal(err)
+	}
+
+	if config.PullStrategy != PullStrategyRebase {
+		t.Errorf("PullStrategy: got %q, expected %q", config.PullStrategy, PullStrategyRebase)
+	}
+
+	if !config.SyncTags {
+		t.Error("expected absent keys to retain defaults")
+	}
+}
+
+func TestLoadFilesRejects(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		content string
+	}{
+		{"unknown key", "colour = \"blue\"\n"},
+		{"syntax", "max_deletions =\n"},
+		{"hooks", "[hooks]\nbefore_stage = \"make\"\n"},
+		{"message command", "message_command = \"date\"\n"},
+		{"message file", "message_file = \"MESSAGE\"\n"},
+		{"nonce path", "nonce_path = \"sync\"\n"},
+		{"block binary", "block_binary = false\n"},
+		{"max file size", "max_file_size = \"1GB\"\n"},
+		{"max total size", "max_total_size = \"1GB\"\n"},
+		{"size policy", "size_policy = \"unstage\"\n"},
+		{"max deletions", "max_deletions = 0\n"},
+		{"max deletion percent", "max_deletion_percent = 100.0\n"},
+		{"max changes", "max_changes = 0\n"},
+		{"scan secrets", "scan_secrets = false\n"},
+		{"skip checks", "skip_checks = [\"upstream\"]\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := writeConfigFile(t, "pull_strategy = \"rebase\"\n"+tc.content)
+			config := NewConfig()
+			config.Dir = dir
+			err := config.LoadFiles()
+			var usageErr UsageError
+
+			if !errors.As(err, &usageErr) {
+				t.Fatalf("expected UsageError, got %v", err)
+			}
+
+			if config.PullStrategy != PullStrategyMerge {
+				t.Errorf("expected configuration to remain unchanged, got PullStrategy %q", config.PullStrategy)
+			}
+		})
+	}
+}
+
+func TestLoadFileAllowsRestrictedKeys(t *testing.T) {
+	dir := writeConfigFile(t, "scan_secrets = false\n[hooks]\nbefore_stage = \"make\"\n")
+	config := NewConfig()
+
+	if err := config.LoadFile(filepath.Join(dir, ConfigFilename)); err != nil {
+		t.Fatal(err)
+	}
+
+	if config.ScanSecrets || config.Hooks.BeforeStage != "make" {
+		t.Errorf("expected restricted keys to load, got %+v", config)
+	}
+}
@@ -0,0 +1,131 @@
+package kick
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ConflictWinnerLocal keeps local versions in place, writing remote versions to conflict copies.
+const ConflictWinnerLocal = "local"
+
+// ConflictWinnerRemote keeps remote versions in place, writing local versions to conflict copies.
+const ConflictWinnerRemote = "remote"
+
+// ConflictWinners lists the supported conflict winners.
+var ConflictWinners = []string{
+	ConflictWinnerLocal,
+	ConflictWinnerRemote,
+}
+
+// ConflictCopyTimestampLayout formats timestamps in conflict copy names.
+const ConflictCopyTimestampLayout = "20060102T150405Z"
+
+// ConflictCopyPath names the conflict copy of a file,
+// as name.conflict-<host>-<timestamp>.ext.
+func ConflictCopyPath(pth string, host string, t time.Time) string {
+	dir, base := path.Split(pth)
+	ext := path.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+
+	// Dotfiles such as .bashrc carry no extension.
+	if stem == "" {
+		stem, ext = base, ""
+	}
+
+	return fmt.Sprintf("%s%s.conflict-%s-%s%s", dir, stem, host, t.UTC().Format(ConflictCopyTimestampLayout), ext)
+}
+
+// rebasing reports whether a rebase is in progress.
+func (o Config) rebasing() bool {
+	return o.gitPathExists("rebase-merge") || o.gitPathExists("rebase-apply")
+}
+
+// stageContent queries one side of a conflicting path from the index.
+//
+// Reports false when the side deleted the path.
+func (o Config) stageContent(stage int, pth string) ([]byte, bool) {
+	result, err := o.git(StepPull, "show", fmt.Sprintf(":%d:%s", stage, pth))
+
+	if err != nil {
+		return nil, false
+	}
+
+	return result.Stdout, true
+}
+
+// writeWorkingFile replaces a repository relative file, preserving any existing permissions.
+//
+// Refuses paths leading outside the repository, whether through ".." or symbolic links.
+func (o Config) writeWorkingFile(pth string, content []byte) error {
+	if err := validateRepositoryPath("working file", pth); err != nil {
+		return err
+	}
+
+	name := filepath.FromSlash(pth)
+
+	return o.inRepository(func(root *os.Root) error {
+		var mode fs.FileMode = 0644
+
+		if fi, err := root.Stat(name); err == nil {
+			mode = fi.Mode().Perm()
+		}
+
+		if err := root.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			return err
+		}
+
+		return root.WriteFile(name, content, mode)
+	})
+}
+
+// copyConflicts resolves conflicting paths by keeping the winning side in place
+// and writing the losing side to a conflict copy.
+func (o Config) copyConflicts(paths []string, host string, t time.Time) error {
+	localStage, remoteStage := o.conflictStages()
+	winnerStage, loserStage := remoteStage, localStage
+
+	if o.ConflictWinner == ConflictWinnerLocal {
+		winnerStage, loserStage = localStage, remoteStage
+	}
+
+	for _, pth := range paths {
+		if loser, ok := o.stageContent(loserStage, pth); ok {
+			copyPath := ConflictCopyPath(pth, host, t)
+
+			if err := o.writeWorkingFile(copyPath, loser); err != nil {
+				return err
+			}
+
+			if _, err := o.git(StepPull, "add", "--", copyPath); err != nil {
+				return err
+			}
+
+			if o.Debug {
+				log.Printf("conflict copy: %s\n", copyPath)
+			}
+		}
+
+		if err := o.resolveWithStage(pth, winnerStage); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// continuePull concludes a merge or rebase after resolving conflicts.
+func (o Config) continuePull() error {
+	if o.rebasing() {
+		_, err := o.git(StepPull, "-c", "core.editor=true", "rebase", "--continue")
+		return err
+	}
+
+	_, err := o.git(StepPull, "commit", "--no-edit")
+	return err
+}
@@ -0,0 +1,228 @@
+package kick
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestConflictCopyPath(t *testing.T) {
+	timestamp := time.Date(2024, time.January, 2, 4, 4, 5, 0, time.FixedZone("CET", 3600))
+
+	for _, tc := range []struct {
+		pth      string
+		expected string
+	}{
+		{"notes.md", "notes.conflict-laptop-20240102T030405Z.md"},
+		{"docs/notes.md", "docs/notes.conflict-laptop-20240102T030405Z.md"},
+		{"archive.tar.gz", "archive.tar.conflict-laptop-20240102T030405Z.gz"},
+		{"Makefile", "Makefile.conflict-laptop-20240102T030405Z"},
+		{".bashrc", ".bashrc.conflict-laptop-20240102T030405Z"},
+		{"home/.bashrc", "home/.bashrc.conflict-laptop-20240102T030405Z"},
+	} {
+		t.Run(tc.pth, func(t *testing.T) {
+			if pth := ConflictCopyPath(tc.pth, "laptop", timestamp); pth != tc.expected {
+				t.Errorf("got %q, expected %q", pth, tc.expected)
+			}
+		})
+	}
+}
+
+// readWorkingFile reads a file from a fake repository, failing the test when missing.
+func readWorkingFile(t *testing.T, dir string, pth string) string {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(pth)))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(content)
+}
+
+// stageResponses answer index stage queries for a conflicting path, omitting deleted sides.
+func stageResponses(pth string, local string, remote string) []fakeResponse {
+	missingErr := errors.New("exit status 128")
+	responses := []fakeResponse{
+		{prefix: "show :2:" + pth, stdout: local},
+		{prefix: "show :3:" + pth, stdout: remote},
+	}
+
+	for i, content := range []string{local, remote} {
+		if content == "" {
+			responses[i] = fakeResponse{prefix: responses[i].prefix, stderr: "fatal: path not in index", err: missingErr}
+		}
+	}
+
+	return responses
+}
+
+func TestCopyConflicts(t *testing.T) {
+	timestamp := time.Date(2024, time.January, 2, 3, 4, 5, 0, time.UTC)
+	copyPath := "docs/a.conflict-laptop-20240102T030405Z.txt"
+
+	for _, tc := range []struct {
+		name      string
+		winner    string
+		local     string
+		remote    string
+		kept      string
+		copied    string
+		called    []string
+		notCalled []string
+	}{
+		{
+			name:   "remote wins",
+			winner: ConflictWinnerRemote,
+			local:  "local\n",
+			remote: "remote\n",
+			kept:   "remote\n",
+			copied: "local\n",
+			called: []string{"add -- " + copyPath, "add -- docs/a.txt"},
+		},
+		{
+			name:   "local wins",
+			winner: ConflictWinnerLocal,
+			local:  "local\n",
+			remote: "remote\n",
+			kept:   "local\n",
+			copied: "remote\n",
+			called: []string{"add -- " + copyPath, "add -- docs/a.txt"},
+		},
+		{
+			name:      "loser deleted",
+			winner:    ConflictWinnerRemote,
+			remote:    "remote\n",
+			kept:      "remote\n",
+			called:    []string{"add -- docs/a.txt"},
+			notCalled: []string{"add -- " + copyPath},
+		},
+		{
+			name:   "winner deleted",
+			winner: ConflictWinnerRemote,
+			local:  "local\n",
+			copied: "local\n",
+			called: []string{"add -- " + copyPath, "rm --quiet --force -- docs/a.txt"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			config, runner := fakeConfig(t, stageResponses("docs/a.txt", tc.local, tc.remote)...)
+			config.ConflictWinner = tc.winner
+
+			if err := config.copyConflicts([]string{"docs/a.txt"}, "laptop", timestamp); err != nil {
+				t.Fatal(err)
+			}
+
+			if tc.kept != "" {
+				if content := readWorkingFile(t, config.Dir, "docs/a.txt"); content != tc.kept {
+					t.Errorf("kept %q, expected %q", content, tc.kept)
+				}
+			}
+
+			if tc.copied != "" {
+				if content := readWorkingFile(t, config.Dir, copyPath); content != tc.copied {
+					t.Errorf("copied %q, expected %q", content, tc.copied)
+				}
+			}
+
+			for _, prefix := range tc.called {
+				if !runner.called(prefix) {
+					t.Errorf("expected git %s, calls: %q", prefix, runner.calls)
+				}
+			}
+
+			for _, prefix := range tc.notCalled {
+				if runner.called(prefix) {
+					t.Errorf("unexpected git %s, calls: %q", prefix, runner.calls)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteWorkingFilePreservesPermissions(t *testing.T) {
+	config, _ := fakeConfig(t)
+	pth := filepath.Join(config.Dir, "run.sh")
+
+	if err := os.WriteFile(pth, []byte("old"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := config.writeWorkingFile("run.sh", []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+
+	fi, err := os.Stat(pth)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fi.Mode().Perm() != 0755 {
+		t.Errorf("got mode %v, expected 0755", fi.Mode().Perm())
+	}
+}
+
+func TestWriteWorkingFileStaysInRepository(t *testing.T) {
+	config, _ := fakeConfig(t)
+	outside := t.TempDir()
+
+	for _, pth := range []string{"../victim", filepath.ToSlash(filepath.Join(outside, "victim"))} {
+		if err := config.writeWorkingFile(pth, []byte("new")); err == nil {
+			t.Errorf("%s: expected a refusal to write outside the repository", pth)
+		}
+	}
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+
+	if err := os.Symlink(outside, filepath.Join(config.Dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := config.writeWorkingFile("link/victim", []byte("new")); err == nil {
+		t.Error("expected a refusal to write through a symbolic link leaving the repository")
+	}
+
+	if _, err := os.Stat(filepath.Join(outside, "victim")); err == nil {
+		t.Error("file written outside the repository")
+	}
+}
+
+func TestHandlePullConflictCopies(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		copyPaths []string
+		resolved  bool
+	}{
+		{"any path", nil, true},
+		{"within conflict copy paths", []string{"docs/"}, true},
+		{"outside conflict copy paths", []string{"notes/"}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			config, runner := fakeConfig(t, stageResponses("docs/a.txt", "local\n", "remote\n")...)
+			runner.responses = append(runner.responses, gitPathResponses(t, config.Dir, "MERGE_HEAD")...)
+			runner.responses = append(runner.responses, fakeResponse{prefix: "diff --name-only -z --diff-filter=U", stdout: "docs/a.txt\x00"})
+			config.ConflictPolicy = ConflictPolicyCopy
+			config.ConflictCopyPaths = tc.copyPaths
+			cause := MergeConflictError{GitError{Step: StepPull, Args: []string{"pull"}, Err: errors.New("exit status 1")}}
+			err := config.handlePullConflict(cause, "before")
+
+			if resolved := err == nil; resolved != tc.resolved {
+				t.Fatalf("got %v, expected resolution %v, calls: %q", err, tc.resolved, runner.calls)
+			}
+
+			if continued := runner.called("commit --no-edit"); continued != tc.resolved {
+				t.Errorf("got merge commit %v, expected %v, calls: %q", continued, tc.resolved, runner.calls)
+			}
+
+			if aborted := runner.called("merge --abort"); aborted == tc.resolved {
+				t.Errorf("got abort %v, expected %v, calls: %q", aborted, !tc.resolved, runner.calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,290 @@
+package kick
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// EnvironmentVariablePrefix denotes the common prefix of kick environment variables.
+const EnvironmentVariablePrefix = "KICK_"
+
+// EnvironmentVariables lists the recognized kick environment variables.
+var EnvironmentVariables = []string{
+	CommitMessageEnvironmentVariable,
+	NonceEnvironmentVariable,
+	NonceModeEnvironmentVariable,
+	FetchAllEnvironmentVariable,
+	PullAllEnvironmentVariable,
+	PushAllEnvironmentVariable,
+	SyncTagsEnvironmentVariable,
+	PullStrategyEnvironmentVariable,
+	AutostashEnvironmentVariable,
+	ConflictPolicyEnvironmentVariable,
+	ConflictWinnerEnvironmentVariable,
+	SkipChecksEnvironmentVariable,
+	SetUpstreamEnvironmentVariable,
+	UpstreamRemoteEnvironmentVariable,
+	MessageModeEnvironmentVariable,
+	SubjectLimitEnvironmentVariable,
+	MessageFileEnvironmentVariable,
+	MessageCommandEnvironmentVariable,
+	IncludeEnvironmentVariable,
+	ExcludeEnvironmentVariable,
+	ScanSecretsEnvironmentVariable,
+	MaxFileSizeEnvironmentVariable,
+	MaxTotalSizeEnvironmentVariable,
+	BlockBinaryEnvironmentVariable,
+	SizePolicyEnvironmentVariable,
+	MaxDeletionsEnvironmentVariable,
+	MaxDeletionPercentEnvironmentVariable,
+	MaxChangesEnvironmentVariable,
+}
+
+// ParseBool interprets common boolean spellings:
+// 1/0, true/false, yes/no, on/off (case insensitive).
+func ParseBool(s string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "1", "true", "yes", "on":
+		return true, nil
+	case "0", "false", "no", "off":
+		return false, nil
+	default:
+		return false, fmt.Errorf("invalid boolean %q, expected one of 1/0, true/false, yes/no, on/off", s)
+	}
+}
+
+// ParseList splits a comma separated list, ignoring blank entries.
+func ParseList(s string) []string {
+	var items []string
+
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// lookupBoolEnv applies a boolean environment variable, when set.
+func lookupBoolEnv(name string, field *bool) error {
+	value, ok := os.LookupEnv(name)
+
+	if !ok {
+		return nil
+	}
+
+	b, err := ParseBool(value)
+
+	if err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+
+	*field = b
+	return nil
+}
+
+// LoadEnvironment merges settings from kick environment variables,
+// reporting invalid values with a UsageError.
+//
+// Variables absent from the environment retain their current values.
+func (o *Config) LoadEnvironment() error {
+	if err := o.loadEnvironment(); err != nil {
+		return UsageError{Err: err}
+	}
+
+	return nil
+}
+
+// loadEnvironment merges settings from kick environment variables.
+func (o *Config) loadEnvironment() error {
+	boolFields := []struct {
+		name  string
+		field *bool
+	}{
+		{NonceEnvironmentVariable, &o.Nonce},
+		{FetchAllEnvironmentVariable, &o.FetchAll},
+		{PullAllEnvironmentVariable, &o.PullAll},
+		{PushAllEnvironmentVariable, &o.PushAll},
+		{SyncTagsEnvironmentVariable, &o.SyncTags},
+		{AutostashEnvironmentVariable, &o.Autostash},
+		{SetUpstreamEnvironmentVariable, &o.SetUpstream},
+		{ScanSecretsEnvironmentVariable, &o.ScanSecrets},
+		{BlockBinaryEnvironmentVariable, &o.BlockBinary},
+	}
+
+	for _, boolField := range boolFields {
+		if err := lookupBoolEnv(boolField.name, boolField.field); err != nil {
+			return err
+		}
+	}
+
+	if commitMessage, ok := os.LookupEnv(CommitMessageEnvironmentVariable); ok {
+		o.CommitMessage = commitMessage
+	}
+
+	if messageMode, ok := os.LookupEnv(MessageModeEnvironmentVariable); ok {
+		if err := validateMessageMode(messageMode); err != nil {
+			return fmt.Errorf("%s: %v", MessageModeEnvironmentVariable, err)
+		}
+
+		o.MessageMode = messageMode
+	}
+
+	if subjectLimit, ok := os.LookupEnv(SubjectLimitEnvironmentVariable); ok {
+		limit, err := strconv.Atoi(strings.TrimSpace(subjectLimit))
+
+		if err == nil {
+			err = validateSubjectLimit(limit)
+		}
+
+		if err != nil {
+			return fmt.Errorf("%s: %v", SubjectLimitEnvironmentVariable, err)
+		}
+
+		o.SubjectLimit = limit
+	}
+
+	if messageFile, ok := os.LookupEnv(MessageFileEnvironmentVariable); ok {
+		o.MessageFile = messageFile
+	}
+
+	if messageCommand, ok := os.LookupEnv(MessageCommandEnvironmentVariable); ok {
+		o.MessageCommand = messageCommand
+	}
+
+	if upstreamRemote, ok := os.LookupEnv(UpstreamRemoteEnvironmentVariable); ok {
+		o.UpstreamRemote = upstreamRemote
+	}
+
+	if nonceMode, ok := os.LookupEnv(NonceModeEnvironmentVariable); ok {
+		if err := validateNonceMode(nonceMode); err != nil {
+			return fmt.Errorf("%s: %v", NonceModeEnvironmentVariable, err)
+		}
+
+		o.NonceMode = nonceMode
+	}
+
+	if pullStrategy, ok := os.LookupEnv(PullStrategyEnvironmentVariable); ok {
+		if err := validatePullStrategy(pullStrategy); err != nil {
+			return fmt.Errorf("%s: %v", PullStrategyEnvironmentVariable, err)
+		}
+
+		o.PullStrategy = pullStrategy
+	}
+
+	if conflictPolicy, ok := os.LookupEnv(ConflictPolicyEnvironmentVariable); ok {
+		if err := validateConflictPolicy(conflictPolicy); err != nil {
+			return fmt.Errorf("%s: %v", ConflictPolicyEnvironmentVariable, err)
+		}
+
+		o.ConflictPolicy = conflictPolicy
+	}
+
+	if conflictWinner, ok := os.LookupEnv(ConflictWinnerEnvironmentVariable); ok {
+		if err := validateConflictWinner(conflictWinner); err != nil {
+			return fmt.Errorf("%s: %v", ConflictWinnerEnvironmentVariable, err)
+		}
+
+		o.ConflictWinner = conflictWinner
+	}
+
+	if include, ok := os.LookupEnv(IncludeEnvironmentVariable); ok {
+		o.Include = ParseList(include)
+	}
+
+	if exclude, ok := os.LookupEnv(ExcludeEnvironmentVariable); ok {
+		o.Exclude = ParseList(exclude)
+	}
+
+	sizeFields := []struct {
+		name  string
+		field *ByteSize
+	}{
+		{MaxFileSizeEnvironmentVariable, &o.MaxFileSize},
+		{MaxTotalSizeEnvironmentVariable, &o.MaxTotalSize},
+	}
+
+	for _, sizeField := range sizeFields {
+		if value, ok := os.LookupEnv(sizeField.name); ok {
+			size, err := ParseByteSize(value)
+
+			if err != nil {
+				return fmt.Errorf("%s: %v", sizeField.name, err)
+			}
+
+			*sizeField.field = size
+		}
+	}
+
+	intFields := []struct {
+		name  string
+		field *int
+	}{
+		{MaxDeletionsEnvironmentVariable, &o.MaxDeletions},
+		{MaxChangesEnvironmentVariable, &o.MaxChanges},
+	}
+
+	for _, intField := range intFields {
+		if value, ok := os.LookupEnv(intField.name); ok {
+			n, err := strconv.Atoi(strings.TrimSpace(value))
+
+			if err != nil {
+				return fmt.Errorf("%s: %v", intField.name, err)
+			}
+
+			*intField.field = n
+		}
+	}
+
+	if maxDeletionPercent, ok := os.LookupEnv(MaxDeletionPercentEnvironmentVariable); ok {
+		percent, err := strconv.ParseFloat(strings.TrimSpace(maxDeletionPercent), 64)
+
+		if err != nil {
+			return fmt.Errorf("%s: %v", MaxDeletionPercentEnvironmentVariable, err)
+		}
+
+		o.MaxDeletionPercent = percent
+	}
+
+	if sizePolicy, ok := os.LookupEnv(SizePolicyEnvironmentVariable); ok {
+		if err := validateSizePolicy(sizePolicy); err != nil {
+			return fmt.Errorf("%s: %v", SizePolicyEnvironmentVariable, err)
+		}
+
+		o.SizePolicy = sizePolicy
+	}
+
+	if skipChecks, ok := os.LookupEnv(SkipChecksEnvironmentVariable); ok {
+		checks := ParseList(skipChecks)
+
+		if err := validateSkipChecks(checks); err != nil {
+			return fmt.Errorf("%s: %v", SkipChecksEnvironmentVariable, err)
+		}
+
+		o.SkipChecks = checks
+	}
+
+	return nil
+}
+
+// UnknownEnvironmentVariables lists any KICK_ prefixed variables
+// absent from EnvironmentVariables and HookEnvironmentVariables, which likely indicate typos.
+func UnknownEnvironmentVariables() []string {
+	var unknowns []string
+
+	for _, pair := range os.Environ() {
+		name, _, _ := strings.Cut(pair, "=")
+
+		if strings.HasPrefix(name, EnvironmentVariablePrefix) &&
+			!slices.Contains(EnvironmentVariables, name) &&
+			!slices.Contains(HookEnvironmentVariables, name) {
+			unknowns = append(unknowns, name)
+		}
+	}
+
+	return unknowns
+}
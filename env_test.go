@@ -0,0 +1,111 @@
+package kick
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestParseBool(t *testing.T) {
+	for _, tc := range []struct {
+		s        string
+		expected bool
+	}{
+		{"1", true},
+		{"true", true},
+		{" YES ", true},
+		{"on", true},
+		{"0", false},
+		{"False", false},
+		{"no", false},
+		{"off", false},
+	} {
+		t.Run(tc.s, func(t *testing.T) {
+			b, err := ParseBool(tc.s)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if b != tc.expected {
+				t.Errorf("got %v, expected %v", b, tc.expected)
+			}
+		})
+	}
+}
+
+func TestParseBoolInvalid(t *testing.T) {
+	for _, s := range []string{"", "maybe", "2"} {
+		t.Run(s, func(t *testing.T) {
+			if _, err := ParseBool(s); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestParseList(t *testing.T) {
+	for _, tc := range []struct {
+		s        string
+		expected []string
+	}{
+		{"", nil},
+		{"a", []string{"a"}},
+		{" a , b ,, c ", []string{"a", "b", "c"}},
+		{",", nil},
+	} {
+		t.Run(tc.s, func(t *testing.T) {
+			if items := ParseList(tc.s); !slices.Equal(items, tc.expected) {
+				t.Errorf("got %q, expected %q", items, tc.expected)
+			}
+		})
+	}
+}
+
+func TestLoadEnvironment(t *testing.T) {
+	t.Setenv(NonceEnvironmentVariable, "yes")
+	t.Setenv(IncludeEnvironmentVariable, "docs/, *.md")
+	t.Setenv(MaxDeletionsEnvironmentVariable, "7")
+	config := NewConfig()
+
+	if err := config.LoadEnvironment(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !config.Nonce {
+		t.Error("expected Nonce")
+	}
+
+	if expected := []string{"docs/", "*.md"}; !slices.Equal(config.Include, expected) {
+		t.Errorf("Include: got %q, expected %q", config.Include, expected)
+	}
+
+	if config.MaxDeletions != 7 {
+		t.Errorf("MaxDeletions: got %d, expected 7", config.MaxDeletions)
+	}
+}
+
+func TestLoadEnvironmentInvalid(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		value string
+	}{
+		{NonceEnvironmentVariable, "maybe"},
+		{MaxDeletionsEnvironmentVariable, "many"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(tc.name, tc.value)
+			config := NewConfig()
+			err := config.LoadEnvironment()
+			var usageErr UsageError
+
+			if !errors.As(err, &usageErr) {
+				t.Errorf("expected UsageError, got %v", err)
+			}
+
+			if ExitCode(err) != ExitUsage {
+				t.Errorf("got exit code %d, expected %d", ExitCode(err), ExitUsage)
+			}
+		})
+	}
+}
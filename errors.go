@@ -0,0 +1,292 @@
+package kick
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// StepRepository labels locating the repository.
+const StepRepository = "repository"
+
+// StepRemotes labels querying remote names.
+const StepRemotes = "remotes"
+
+// StepNonce labels updating the nonce file.
+const StepNonce = "nonce"
+
+// StepStage labels staging local file changes.
+const StepStage = "stage"
+
+// StepCommit labels committing staged changes.
+const StepCommit = "commit"
+
+// StepPull labels pulling remote changes.
+const StepPull = "pull"
+
+// StepPush labels pushing local changes.
+const StepPush = "push"
+
+// StepFetchTags labels fetching remote tags.
+const StepFetchTags = "fetch-tags"
+
+// StepPushTags labels pushing local tags.
+const StepPushTags = "push-tags"
+
+// StepQuery labels read-only repository queries.
+const StepQuery = "query"
+
+// StepPreflight labels verifying that the repository is safe to sync.
+const StepPreflight = "preflight"
+
+// StepSecrets labels scanning staged changes for secrets.
+const StepSecrets = "secrets"
+
+// StepSizes labels checking staged file sizes.
+const StepSizes = "sizes"
+
+// StepMassChange labels checking staged changes for mass deletions and changes.
+const StepMassChange = "mass-change"
+
+// StepHook labels running hook commands.
+const StepHook = "hook"
+
+// GitError reports a failed git step.
+type GitError struct {
+	// Step names the failed Kick step, such as StepPull.
+	Step string
+
+	// Args denotes the arguments following the git executable.
+	Args []string
+
+	// Output collects git's standard output and standard error.
+	Output string
+
+	// Err denotes the underlying failure.
+	Err error
+}
+
+// Error renders the failed step along with git output.
+func (o GitError) Error() string {
+	message := fmt.Sprintf("%s: git %s: %v", o.Step, strings.Join(o.Args, " "), o.Err)
+
+	if output := strings.TrimSpace(o.Output); output != "" {
+		message = fmt.Sprintf("%s\n%s", message, output)
+	}
+
+	return message
+}
+
+// Unwrap exposes the underlying failure.
+func (o GitError) Unwrap() error { return o.Err }
+
+// AuthenticationError reports rejected or missing remote credentials.
+type AuthenticationError struct{ GitError }
+
+// Unwrap exposes the general GitError.
+func (o AuthenticationError) Unwrap() error { return o.GitError }
+
+// MergeConflictError reports conflicting changes.
+type MergeConflictError struct{ GitError }
+
+// Unwrap exposes the general GitError.
+func (o MergeConflictError) Unwrap() error { return o.GitError }
+
+// NonFastForwardError reports a push rejected for lacking remote changes.
+type NonFastForwardError struct{ GitError }
+
+// Unwrap exposes the general GitError.
+func (o NonFastForwardError) Unwrap() error { return o.GitError }
+
+// NoUpstreamError reports a branch without a tracking branch.
+type NoUpstreamError struct{ GitError }
+
+// Unwrap exposes the general GitError.
+func (o NoUpstreamError) Unwrap() error { return o.GitError }
+
+// RemoteUnreachableError reports a remote that could not be contacted.
+type RemoteUnreachableError struct{ GitError }
+
+// Unwrap exposes the general GitError.
+func (o RemoteUnreachableError) Unwrap() error { return o.GitError }
+
+// UsageError reports invalid settings, from configuration files, environment variables, or flags.
+type UsageError struct {
+	// Err denotes the underlying failure.
+	Err error
+}
+
+// Error renders the invalid setting.
+func (o UsageError) Error() string { return o.Err.Error() }
+
+// Unwrap exposes the underlying failure.
+func (o UsageError) Unwrap() error { return o.Err }
+
+// errNothingStaged reports an empty index.
+var errNothingStaged = errors.New("nothing staged")
+
+// RepositoryNotFoundError reports a remote URL pointing at no repository, such as from a typo.
+type RepositoryNotFoundError struct{ GitError }
+
+// Unwrap exposes the general GitError.
+func (o RepositoryNotFoundError) Unwrap() error { return o.GitError }
+
+// NothingToCommitError reports an unchanged working tree.
+type NothingToCommitError struct{ GitError }
+
+// Unwrap exposes the general GitError.
+func (o NothingToCommitError) Unwrap() error { return o.GitError }
+
+// HookRejectionError reports a change declined by a git hook.
+type HookRejectionError struct{ GitError }
+
+// Unwrap exposes the general GitError.
+func (o HookRejectionError) Unwrap() error { return o.GitError }
+
+// PullConflictError reports conflicting paths from a pull, after applying the conflict policy.
+type PullConflictError struct {
+	MergeConflictError
+
+	// Paths lists the conflicting paths.
+	Paths []string
+
+	// Restored denotes the pre-pull commit restored by aborting,
+	// or a blank string when the conflict was left in place.
+	Restored string
+}
+
+// Error renders the conflicting paths along with git output.
+func (o PullConflictError) Error() string {
+	resolution := "left in place for manual resolution"
+
+	if o.Restored != "" {
+		resolution = fmt.Sprintf("aborted, restored %s", o.Restored)
+	}
+
+	return fmt.Sprintf(
+		"conflicting paths (%s): %s\n%s",
+		resolution,
+		strings.Join(o.Paths, ", "),
+		o.MergeConflictError.Error(),
+	)
+}
+
+// Unwrap exposes the general MergeConflictError.
+func (o PullConflictError) Unwrap() error { return o.MergeConflictError }
+
+// authenticationPatterns match git output for AuthenticationError.
+var authenticationPatterns = []string{
+	"authentication failed",
+	"permission denied (publickey",
+	"could not read username",
+	"could not read password",
+	"terminal prompts disabled",
+	"invalid username or password",
+	"access denied",
+	"requested url returned error: 401",
+	"requested url returned error: 403",
+}
+
+// hookRejectionPatterns match git output for HookRejectionError.
+var hookRejectionPatterns = []string{
+	"hook declined",
+	"[remote rejected]",
+}
+
+// mergeConflictPatterns match git output for MergeConflictError.
+var mergeConflictPatterns = []string{
+	"conflict (",
+	"automatic merge failed",
+	"could not apply",
+	"you have unmerged paths",
+	"fix conflicts and then commit",
+	"resolve all conflicts manually",
+}
+
+// nonFastForwardPatterns match git output for NonFastForwardError.
+var nonFastForwardPatterns = []string{
+	"non-fast-forward",
+	"(fetch first)",
+	"updates were rejected",
+}
+
+// noUpstreamPatterns match git output for NoUpstreamError.
+var noUpstreamPatterns = []string{
+	"has no upstream branch",
+	"there is no tracking information",
+	"no upstream configured",
+	"no such ref was fetched",
+}
+
+// repositoryNotFoundPatterns match git output for RepositoryNotFoundError.
+var repositoryNotFoundPatterns = []string{
+	"repository not found",
+	"does not appear to be a git repository",
+	"requested url returned error: 404",
+}
+
+// remoteUnreachablePatterns match git output for RemoteUnreachableError.
+//
+// Patterns cover network failures only, as callers may safely retry these.
+var remoteUnreachablePatterns = []string{
+	"could not resolve host",
+	"could not resolve hostname",
+	"temporary failure in name resolution",
+	"failed to connect to",
+	"connection refused",
+	"connection reset by peer",
+	"connection timed out",
+	"operation timed out",
+	"network is unreachable",
+	"no route to host",
+}
+
+// nothingToCommitPatterns match git output for NothingToCommitError.
+var nothingToCommitPatterns = []string{
+	"nothing to commit",
+	"nothing added to commit",
+	"no changes added to commit",
+}
+
+// matchesAny reports whether lowercase git output contains any of the given patterns.
+func matchesAny(output string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if strings.Contains(output, pattern) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Classify wraps a git failure in the most specific error type
+// recognizable from git's output, falling back to GitError.
+func Classify(step string, args []string, output string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	e := GitError{Step: step, Args: args, Output: output, Err: err}
+	lowerOutput := strings.ToLower(output)
+
+	switch {
+	case matchesAny(lowerOutput, authenticationPatterns):
+		return AuthenticationError{e}
+	case matchesAny(lowerOutput, hookRejectionPatterns):
+		return HookRejectionError{e}
+	case matchesAny(lowerOutput, mergeConflictPatterns):
+		return MergeConflictError{e}
+	case matchesAny(lowerOutput, nonFastForwardPatterns):
+		return NonFastForwardError{e}
+	case matchesAny(lowerOutput, noUpstreamPatterns):
+		return NoUpstreamError{e}
+	case matchesAny(lowerOutput, repositoryNotFoundPatterns):
+		return RepositoryNotFoundError{e}
+	case matchesAny(lowerOutput, remoteUnreachablePatterns):
+		return RemoteUnreachableError{e}
+	case matchesAny(lowerOutput, nothingToCommitPatterns):
+		return NothingToCommitError{e}
+	default:
+		return e
+	}
+}
@@ -0,0 +1,50 @@
+package kick
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	exitErr := errors.New("exit status 128")
+
+	for _, tc := range []struct {
+		output   string
+		expected error
+	}{
+		{"fatal: Authentication failed for 'https://example.com/r.git/'", AuthenticationError{}},
+		{"fatal: unable to access 'https://example.com/r.git/': The requested URL returned error: 403", AuthenticationError{}},
+		{"! [remote rejected] main -> main (pre-receive hook declined)", HookRejectionError{}},
+		{"CONFLICT (content): Merge conflict in a.txt\nAutomatic merge failed", MergeConflictError{}},
+		{"error: Your local changes to the following files would be overwritten by merge:\n\ta.txt\nPlease commit your changes or stash them before you merge.\nAborting", GitError{}},
+		{"error: The following untracked working tree files would be overwritten by merge:\n\ta.txt\nPlease move or remove them before you merge.\nAborting", GitError{}},
+		{"! [rejected] main -> main (fetch first)", NonFastForwardError{}},
+		{"fatal: The current branch main has no upstream branch.", NoUpstreamError{}},
+		{"remote: Repository not found.", RepositoryNotFoundError{}},
+		{"fatal: '/tmp/missing.git' does not appear to be a git repository", RepositoryNotFoundError{}},
+		{"fatal: unable to access 'https://example.com/r.git/': The requested URL returned error: 404", RepositoryNotFoundError{}},
+		{"ssh: Could not resolve hostname example.com: Name or service not known", RemoteUnreachableError{}},
+		{"fatal: unable to access 'https://example.com/': Failed to connect to example.com port 443", RemoteUnreachableError{}},
+		{"nothing added to commit but untracked files present", NothingToCommitError{}},
+		{"fatal: something else", GitError{}},
+	} {
+		t.Run(tc.output, func(t *testing.T) {
+			err := Classify(StepPush, []string{"push"}, tc.output, exitErr)
+
+			if reflect.TypeOf(err) != reflect.TypeOf(tc.expected) {
+				t.Errorf("got %T, expected %T", err, tc.expected)
+			}
+
+			if !errors.Is(err, exitErr) {
+				t.Errorf("%v does not wrap %v", err, exitErr)
+			}
+		})
+	}
+}
+
+func TestClassifySuccess(t *testing.T) {
+	if err := Classify(StepPush, []string{"push"}, "nothing to commit", nil); err != nil {
+		t.Errorf("got %v, expected nil", err)
+	}
+}
@@ -0,0 +1,101 @@
+package kick
+
+import (
+	"errors"
+)
+
+// ExitSuccess denotes a successful run.
+const ExitSuccess = 0
+
+// ExitFailure denotes an unclassified failure.
+const ExitFailure = 1
+
+// ExitUsage denotes invalid settings, from configuration files, environment variables, or flags.
+const ExitUsage = 2
+
+// ExitStage denotes a failure staging local file changes.
+const ExitStage = 3
+
+// ExitCommit denotes a failure committing staged changes.
+const ExitCommit = 4
+
+// ExitPullConflict denotes conflicting remote changes.
+const ExitPullConflict = 5
+
+// ExitPushRejected denotes a push declined by a remote.
+const ExitPushRejected = 6
+
+// ExitTagSync denotes a failure fetching or pushing tags.
+const ExitTagSync = 7
+
+// ExitRemoteUnreachable denotes a remote that could not be contacted.
+//
+// Usually transient, and safe to retry.
+const ExitRemoteUnreachable = 8
+
+// ExitPrecondition denotes a repository unfit for syncing.
+const ExitPrecondition = 9
+
+// ExitAuthentication denotes rejected or missing remote credentials.
+const ExitAuthentication = 10
+
+// ExitGuard denotes a commit refused by a safety guard, such as secret scanning, size limits, mass change limits,
+// or a failing before hook.
+const ExitGuard = 11
+
+// ExitCode maps a Kick error to a process exit status.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitSuccess
+	}
+
+	var authenticationErr AuthenticationError
+	var mergeConflictErr MergeConflictError
+	var nonFastForwardErr NonFastForwardError
+	var noUpstreamErr NoUpstreamError
+	var remoteUnreachableErr RemoteUnreachableError
+	var repositoryNotFoundErr RepositoryNotFoundError
+	var hookRejectionErr HookRejectionError
+	var usageErr UsageError
+	var preflightErr PreflightError
+	var secretsErr SecretsError
+	var sizeErr SizeError
+	var massChangeErr MassChangeError
+	var hookCommandErr HookCommandError
+	var gitErr GitError
+
+	switch {
+	case errors.As(err, &usageErr):
+		return ExitUsage
+	case errors.As(err, &preflightErr):
+		return ExitPrecondition
+	case errors.As(err, &secretsErr), errors.As(err, &sizeErr), errors.As(err, &massChangeErr),
+		errors.As(err, &hookCommandErr):
+		return ExitGuard
+	case errors.As(err, &remoteUnreachableErr):
+		return ExitRemoteUnreachable
+	case errors.As(err, &authenticationErr):
+		return ExitAuthentication
+	case errors.As(err, &mergeConflictErr):
+		return ExitPullConflict
+	case errors.As(err, &noUpstreamErr), errors.As(err, &repositoryNotFoundErr):
+		return ExitPrecondition
+	case errors.As(err, &nonFastForwardErr):
+		return ExitPushRejected
+	case errors.As(err, &hookRejectionErr) && hookRejectionErr.Step == StepPush:
+		return ExitPushRejected
+	case errors.As(err, &gitErr):
+		switch gitErr.Step {
+		case StepRepository, StepRemotes, StepPreflight:
+			return ExitPrecondition
+		case StepStage:
+			return ExitStage
+		case StepCommit:
+			return ExitCommit
+		case StepFetchTags, StepPushTags:
+			return ExitTagSync
+		}
+	}
+
+	return ExitFailure
+}
@@ -0,0 +1,101 @@
+package kick
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{"success", nil, ExitSuccess},
+		{"usage", UsageError{Err: errors.New("invalid")}, ExitUsage},
+		{"wrapped usage", fmt.Errorf("config: %w", UsageError{Err: errors.New("invalid")}), ExitUsage},
+		{"stage", GitError{Step: StepStage}, ExitStage},
+		{"commit", GitError{Step: StepCommit}, ExitCommit},
+		{"pull conflict", MergeConflictError{GitError{Step: StepPull}}, ExitPullConflict},
+		{"push rejected", NonFastForwardError{GitError{Step: StepPush}}, ExitPushRejected},
+		{"push hook", HookRejectionError{GitError{Step: StepPush}}, ExitPushRejected},
+		{"commit hook", HookRejectionError{GitError{Step: StepCommit}}, ExitCommit},
+		{"tags", GitError{Step: StepPushTags}, ExitTagSync},
+		{"unreachable", RemoteUnreachableError{GitError{Step: StepPull}}, ExitRemoteUnreachable},
+		{"missing repository", RepositoryNotFoundError{GitError{Step: StepPull}}, ExitPrecondition},
+		{"no upstream", NoUpstreamError{GitError{Step: StepPull}}, ExitPrecondition},
+		{"authentication", AuthenticationError{GitError{Step: StepPush}}, ExitAuthentication},
+		{"size", SizeError{}, ExitGuard},
+		{"hook command", HookCommandError{Hook: HookBeforeStage, Err: errors.New("exit status 1")}, ExitGuard},
+		{"other", errors.New("boom"), ExitFailure},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if code := ExitCode(tc.err); code != tc.expected {
+				t.Errorf("got %d, expected %d", code, tc.expected)
+			}
+		})
+	}
+}
+
+func TestKickExitCode(t *testing.T) {
+	exitErr := errors.New("exit status 1")
+
+	for _, tc := range []struct {
+		name      string
+		responses []fakeResponse
+		expected  int
+		notCalled []string
+	}{
+		{
+			name:      "push rejected",
+			responses: []fakeResponse{{prefix: "push --all", stderr: "! [rejected] main -> main (fetch first)\n", err: exitErr}},
+			expected:  ExitPushRejected,
+			notCalled: []string{"fetch --tags"},
+		},
+		{
+			name:      "unreachable remote",
+			responses: []fakeResponse{{prefix: "pull", stderr: "fatal: Could not resolve host: example.com\n", err: exitErr}},
+			expected:  ExitRemoteUnreachable,
+			notCalled: []string{"push"},
+		},
+		{
+			name:      "authentication",
+			responses: []fakeResponse{{prefix: "push --all", stderr: "fatal: Authentication failed\n", err: exitErr}},
+			expected:  ExitAuthentication,
+		},
+		{
+			name:      "tags",
+			responses: []fakeResponse{{prefix: "fetch --tags", stderr: "fatal: bad tag\n", err: exitErr}},
+			expected:  ExitTagSync,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			config, runner := fakeConfig(t, tc.responses...)
+
+			if code := ExitCode(config.Kick()); code != tc.expected {
+				t.Errorf("got %d, expected %d, calls: %q", code, tc.expected, runner.calls)
+			}
+
+			for _, prefix := range tc.notCalled {
+				if runner.called(prefix) {
+					t.Errorf("unexpected git %s, calls: %q", prefix, runner.calls)
+				}
+			}
+		})
+	}
+}
+
+func TestKickRejectsInvalidSettings(t *testing.T) {
+	config, runner := fakeConfig(t)
+	config.PullStrategy = "sideways"
+	err := config.Kick()
+
+	if code := ExitCode(err); code != ExitUsage {
+		t.Errorf("got %d, expected %d: %v", code, ExitUsage, err)
+	}
+
+	if len(runner.calls) != 0 {
+		t.Errorf("unexpected git calls: %q", runner.calls)
+	}
+}
@@ -0,0 +1,65 @@
+package kick
+
+import (
+	"path"
+	"strings"
+)
+
+// MatchPath reports whether a slash separated, repository relative path matches a glob pattern.
+//
+// Patterns follow path.Match syntax, plus ** matching any number of directories.
+// Patterns without a slash match the base name at any depth, like .gitignore entries.
+// A leading slash anchors a pattern to the repository's top level directory.
+// Patterns ending in a slash match everything beneath a directory.
+func MatchPath(pattern string, pth string) bool {
+	anchored := strings.HasPrefix(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+
+	if !anchored && !strings.Contains(pattern, "/") {
+		pattern = "**/" + pattern
+	}
+
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(pth, "/"))
+}
+
+// matchSegments matches path segments against pattern segments.
+func matchSegments(patterns []string, segments []string) bool {
+	if len(patterns) == 0 {
+		return len(segments) == 0
+	}
+
+	if patterns[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(patterns[1:], segments[i:]) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if len(segments) == 0 {
+		return false
+	}
+
+	if matched, err := path.Match(patterns[0], segments[0]); err != nil || !matched {
+		return false
+	}
+
+	return matchSegments(patterns[1:], segments[1:])
+}
+
+// matchAnyPath reports whether a path matches any of the given glob patterns.
+func matchAnyPath(patterns []string, pth string) bool {
+	for _, pattern := range patterns {
+		if MatchPath(pattern, pth) {
+			return true
+		}
+	}
+
+	return false
+}
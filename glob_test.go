@@ -0,0 +1,33 @@
+package kick
+
+import "testing"
+
+func TestMatchPath(t *testing.T) {
+	for _, tc := range []struct {
+		pattern  string
+		pth      string
+		expected bool
+	}{
+		{"*.log", "debug.log", true},
+		{"*.log", "logs/debug.log", true},
+		{"*.log", "debug.txt", false},
+		{"build/", "build/a.o", true},
+		{"/.kick", ".kick", true},
+		{"/.kick", "sub/.kick", false},
+		{".kick", "sub/.kick", true},
+		{"/build/", "build/out/a.o", true},
+		{"/build/", "src/build/a.o", false},
+		{"docs/*.md", "docs/a.md", true},
+		{"docs/*.md", "docs/sub/a.md", false},
+		{"docs/**/*.md", "docs/sub/deep/a.md", true},
+		{"docs/**/*.md", "docs/a.md", true},
+		{"**/vendor/**", "a/vendor/b/c.go", true},
+		{"[", "[", false},
+	} {
+		t.Run(tc.pattern+" "+tc.pth, func(t *testing.T) {
+			if matched := MatchPath(tc.pattern, tc.pth); matched != tc.expected {
+				t.Errorf("got %v, expected %v", matched, tc.expected)
+			}
+		})
+	}
+}
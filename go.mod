@@ -3,12 +3,12 @@ module github.com/mcandre/kick
 go 1.25.3
 
 require (
+	github.com/BurntSushi/toml v1.5.0
 	github.com/magefile/mage v1.15.0
 	github.com/mcandre/mage-extras v0.0.28
 )
 
 require (
-	github.com/BurntSushi/toml v1.5.0 // indirect
 	github.com/alexkohler/nakedret/v2 v2.0.6 // indirect
 	github.com/kisielk/errcheck v1.9.0 // indirect
 	github.com/mcandre/factorio v0.0.15 // indirect
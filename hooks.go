@@ -0,0 +1,161 @@
+package kick
+
+import (
+	"fmt"
+	"log"
+	"os"
+)
+
+// HookBeforeStage runs before staging, aborting the kick on failure.
+const HookBeforeStage = "before_stage"
+
+// HookBeforeCommit runs before committing, aborting the kick on failure.
+const HookBeforeCommit = "before_commit"
+
+// HookAfterPull runs after a pull brings in new commits.
+const HookAfterPull = "after_pull"
+
+// HookAfterPush runs after pushing.
+const HookAfterPush = "after_push"
+
+// HookOnFailure runs when a kick fails.
+const HookOnFailure = "on_failure"
+
+// HookEnvironmentVariable denotes the name of the environment variable naming the running hook.
+const HookEnvironmentVariable = "KICK_HOOK"
+
+// RepoEnvironmentVariable denotes the name of the environment variable locating the repository's top level directory for hooks.
+const RepoEnvironmentVariable = "KICK_REPO"
+
+// BranchEnvironmentVariable denotes the name of the environment variable naming the checked out branch for hooks.
+const BranchEnvironmentVariable = "KICK_BRANCH"
+
+// CommitEnvironmentVariable denotes the name of the environment variable identifying the checked out commit for hooks.
+const CommitEnvironmentVariable = "KICK_COMMIT"
+
+// PullRangeEnvironmentVariable denotes the name of the environment variable describing the commits brought in by a pull for hooks,
+// as old..new.
+const PullRangeEnvironmentVariable = "KICK_PULL_RANGE"
+
+// ErrorEnvironmentVariable denotes the name of the environment variable describing the failure for HookOnFailure.
+const ErrorEnvironmentVariable = "KICK_ERROR"
+
+// HookEnvironmentVariables lists the environment variables kick provides to hooks.
+var HookEnvironmentVariables = []string{
+	HookEnvironmentVariable,
+	RepoEnvironmentVariable,
+	BranchEnvironmentVariable,
+	CommitEnvironmentVariable,
+	PullRangeEnvironmentVariable,
+	ErrorEnvironmentVariable,
+}
+
+// HookCommands denotes shell commands run at points during Kick,
+// in the repository's top level directory.
+type HookCommands struct {
+	// BeforeStage runs before staging, aborting the kick on failure.
+	BeforeStage string `toml:"before_stage"`
+
+	// BeforeCommit runs before committing, aborting the kick on failure.
+	BeforeCommit string `toml:"before_commit"`
+
+	// AfterPull runs after a pull brings in new commits.
+	AfterPull string `toml:"after_pull"`
+
+	// AfterPush runs after pushing.
+	AfterPush string `toml:"after_push"`
+
+	// OnFailure runs when a kick fails.
+	OnFailure string `toml:"on_failure"`
+}
+
+// HookCommandError reports a failed hook command.
+type HookCommandError struct {
+	// Hook names the hook, such as HookBeforeStage.
+	Hook string
+
+	// Command denotes the shell command.
+	Command string
+
+	// Err denotes the underlying failure.
+	Err error
+}
+
+// Error renders the failed hook.
+func (o HookCommandError) Error() string {
+	return fmt.Sprintf("hook %s: %s: %v", o.Hook, o.Command, o.Err)
+}
+
+// Unwrap exposes the underlying failure.
+func (o HookCommandError) Unwrap() error { return o.Err }
+
+// hookOperations describes running a hook command, if any.
+func hookOperations(hook string, command string) []Operation {
+	if command == "" {
+		return nil
+	}
+
+	return []Operation{{Step: StepHook, Description: fmt.Sprintf("hook %s: %s", hook, command)}}
+}
+
+// runHook runs a hook command, if any, describing the repository in environment variables.
+func (o Config) runHook(hook string, command string, env ...string) error {
+	if command == "" {
+		return nil
+	}
+
+	cmd := o.shell(command)
+	cmd.Env = append(
+		cmd.Environ(),
+		fmt.Sprintf("%s=%s", HookEnvironmentVariable, hook),
+		fmt.Sprintf("%s=%s", RepoEnvironmentVariable, o.Dir),
+		fmt.Sprintf("%s=%s", BranchEnvironmentVariable, o.currentBranch()),
+		fmt.Sprintf("%s=%s", CommitEnvironmentVariable, o.head()),
+		fmt.Sprintf("%s=%s", PullRangeEnvironmentVariable, o.pullRange),
+	)
+	cmd.Env = append(cmd.Env, env...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if o.Debug {
+		log.Printf("hook %s: %v\n", hook, cmd)
+	}
+
+	if err := cmd.Run(); err != nil {
+		return HookCommandError{Hook: hook, Command: command, Err: err}
+	}
+
+	return nil
+}
+
+// runAfterHook runs a hook command, if any, logging rather than propagating failures.
+func (o Config) runAfterHook(hook string, command string) {
+	if err := o.runHook(hook, command); err != nil {
+		log.Println(err)
+	}
+}
+
+// pull pulls any remote changes, recording the pulled range
+// and running HookAfterPull when new commits arrive.
+func (o *Config) pull() error {
+	head := o.head()
+
+	if err := o.Pull(); err != nil {
+		return err
+	}
+
+	pulled := o.head()
+
+	if pulled == head {
+		return nil
+	}
+
+	o.pullRange = pulled
+
+	if head != "" {
+		o.pullRange = fmt.Sprintf("%s..%s", head, pulled)
+	}
+
+	o.runAfterHook(HookAfterPull, o.Hooks.AfterPull)
+	return nil
+}
@@ -0,0 +1,115 @@
+package kick
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestKickHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook fixtures use sh")
+	}
+
+	exitErr := errors.New("exit status 1")
+	branch := fakeResponse{prefix: "symbolic-ref --quiet --short HEAD", stdout: "main\n"}
+	head := fakeResponse{prefix: "rev-parse --verify --quiet HEAD", stdout: "abc123\n"}
+
+	for _, tc := range []struct {
+		name      string
+		hooks     HookCommands
+		responses []fakeResponse
+		code      int
+		files     map[string]string
+		notCalled []string
+	}{
+		{
+			name: "environment",
+			hooks: HookCommands{
+				BeforeStage: `printf '%s %s %s' "$KICK_HOOK" "$KICK_BRANCH" "$KICK_COMMIT" > before_stage.txt`,
+				AfterPush:   `printf '%s' "$KICK_HOOK" > after_push.txt`,
+			},
+			code:  ExitSuccess,
+			files: map[string]string{"before_stage.txt": "before_stage main abc123", "after_push.txt": "after_push"},
+		},
+		{
+			name:      "before stage aborts",
+			hooks:     HookCommands{BeforeStage: "exit 1"},
+			code:      ExitGuard,
+			notCalled: []string{"add", "commit", "push"},
+		},
+		{
+			name:      "before commit aborts",
+			hooks:     HookCommands{BeforeCommit: "exit 1"},
+			responses: []fakeResponse{{prefix: "diff --cached --name-status", stdout: "M\x00a.txt\x00"}},
+			code:      ExitGuard,
+			notCalled: []string{"commit", "push"},
+		},
+		{
+			name:  "after push failure is logged",
+			hooks: HookCommands{AfterPush: "exit 1"},
+			code:  ExitSuccess,
+		},
+		{
+			name:      "on failure",
+			hooks:     HookCommands{OnFailure: `printf '%s' "$KICK_ERROR" > on_failure.txt`},
+			responses: []fakeResponse{{prefix: "push --all", stderr: "! [rejected] main -> main (fetch first)\n", err: exitErr}},
+			code:      ExitPushRejected,
+			files:     map[string]string{"on_failure.txt": "push: git push --all: exit status 1\n! [rejected] main -> main (fetch first)"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			config, runner := fakeConfig(t, append(tc.responses, branch, head)...)
+			config.Hooks = tc.hooks
+
+			if code := ExitCode(config.Kick()); code != tc.code {
+				t.Errorf("got exit code %d, expected %d, calls: %q", code, tc.code, runner.calls)
+			}
+
+			for pth, expected := range tc.files {
+				if content := readWorkingFile(t, config.Dir, pth); content != expected {
+					t.Errorf("%s: got %q, expected %q", pth, content, expected)
+				}
+			}
+
+			for _, prefix := range tc.notCalled {
+				if runner.called(prefix) {
+					t.Errorf("unexpected git %s, calls: %q", prefix, runner.calls)
+				}
+			}
+		})
+	}
+}
+
+func TestKickSkipsOnFailureForInvalidSettings(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook fixtures use sh")
+	}
+
+	config, _ := fakeConfig(t)
+	config.PullStrategy = "sideways"
+	config.Hooks.OnFailure = "touch on_failure.txt"
+
+	if code := ExitCode(config.Kick()); code != ExitUsage {
+		t.Errorf("got exit code %d, expected %d", code, ExitUsage)
+	}
+
+	if _, err := os.Stat(filepath.Join(config.Dir, "on_failure.txt")); err == nil {
+		t.Error("unexpected on_failure hook")
+	}
+}
+
+func TestHookOperations(t *testing.T) {
+	if operations := hookOperations(HookAfterPush, ""); len(operations) != 0 {
+		t.Errorf("got %+v, expected no operations", operations)
+	}
+
+	operations := hookOperations(HookAfterPush, "make notify")
+
+	if len(operations) != 1 || !strings.Contains(operations[0].Description, "after_push: make notify") {
+		t.Errorf("got %+v, expected a hook operation", operations)
+	}
+}
@@ -31,7 +31,10 @@ func Audit() error {
 }
 
 // Test executes a test suite.
-func Test() error { return IntegrationTest() }
+func Test() error { mg.Deps(UnitTest); return IntegrationTest() }
+
+// UnitTest executes unit tests.
+func UnitTest() error { return mageextras.UnitTest("./...") }
 
 // IntegrationTest executes kick operations.
 func IntegrationTest() error {
@@ -0,0 +1,221 @@
+package kick
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+)
+
+// MergeStrategyOurs resolves conflicts with the local version.
+const MergeStrategyOurs = "ours"
+
+// MergeStrategyTheirs resolves conflicts with the remote version.
+const MergeStrategyTheirs = "theirs"
+
+// MergeStrategyUnion resolves conflicts by keeping the lines of both versions.
+const MergeStrategyUnion = "union"
+
+// MergeStrategyNewest resolves conflicts with the most recently committed version.
+const MergeStrategyNewest = "newest"
+
+// MergeStrategies lists the supported per-path merge strategies.
+var MergeStrategies = []string{
+	MergeStrategyOurs,
+	MergeStrategyTheirs,
+	MergeStrategyUnion,
+	MergeStrategyNewest,
+}
+
+// MergeRule resolves conflicts in matching paths automatically during pulls.
+type MergeRule struct {
+	// Path denotes a glob pattern, per MatchPath.
+	Path string `toml:"path"`
+
+	// Strategy denotes one of MergeStrategies.
+	Strategy string `toml:"strategy"`
+}
+
+// validateMergeRules rejects unsupported merge rules.
+func validateMergeRules(rules []MergeRule) error {
+	for _, rule := range rules {
+		if rule.Path == "" {
+			return fmt.Errorf("merge rule missing path")
+		}
+
+		if !slices.Contains(MergeStrategies, rule.Strategy) {
+			return fmt.Errorf("merge rule %s: unsupported strategy %q, expected one of %v", rule.Path, rule.Strategy, MergeStrategies)
+		}
+	}
+
+	return nil
+}
+
+// mergeStrategy selects the strategy of the first MergeRule matching a path.
+func (o Config) mergeStrategy(pth string) (string, bool) {
+	for _, rule := range o.mergeRules() {
+		if MatchPath(rule.Path, pth) {
+			return rule.Strategy, true
+		}
+	}
+
+	return "", false
+}
+
+// conflictStages identifies the index stages holding the local and remote versions of conflicting paths.
+func (o Config) conflictStages() (int, int) {
+	// Rebases replay local commits onto remote changes, swapping ours and theirs.
+	if o.rebasing() {
+		return 3, 2
+	}
+
+	return 2, 3
+}
+
+// conflictRefs identifies the commits contributing the local and remote versions of conflicting paths.
+func (o Config) conflictRefs() (string, string) {
+	if o.rebasing() {
+		return "REBASE_HEAD", "HEAD"
+	}
+
+	return "HEAD", "MERGE_HEAD"
+}
+
+// resolveWithStage resolves a conflicting path with one side.
+func (o Config) resolveWithStage(pth string, stage int) error {
+	content, ok := o.stageContent(stage, pth)
+
+	if !ok {
+		_, err := o.git(StepPull, "rm", "--quiet", "--force", "--", pth)
+		return err
+	}
+
+	if err := o.writeWorkingFile(pth, content); err != nil {
+		return err
+	}
+
+	_, err := o.git(StepPull, "add", "--", pth)
+	return err
+}
+
+// commitTime queries the Unix time of the latest commit touching a path, as of a ref.
+func (o Config) commitTime(ref string, pth string) int64 {
+	lines, err := o.gitLines(StepPull, "log", "-1", "--format=%ct", ref, "--", pth)
+
+	if err != nil || len(lines) == 0 {
+		return 0
+	}
+
+	t, err := strconv.ParseInt(lines[0], 10, 64)
+
+	if err != nil {
+		return 0
+	}
+
+	return t
+}
+
+// resolveNewest resolves a conflicting path with the most recently committed side,
+// preferring the remote side on ties.
+func (o Config) resolveNewest(pth string) error {
+	localStage, remoteStage := o.conflictStages()
+	localRef, remoteRef := o.conflictRefs()
+
+	if o.commitTime(localRef, pth) > o.commitTime(remoteRef, pth) {
+		return o.resolveWithStage(pth, localStage)
+	}
+
+	return o.resolveWithStage(pth, remoteStage)
+}
+
+// resolveUnion resolves a conflicting path by keeping the lines of both sides.
+func (o Config) resolveUnion(pth string) error {
+	localStage, remoteStage := o.conflictStages()
+	local, localOK := o.stageContent(localStage, pth)
+	remote, remoteOK := o.stageContent(remoteStage, pth)
+
+	if !localOK {
+		return o.resolveWithStage(pth, remoteStage)
+	}
+
+	if !remoteOK {
+		return o.resolveWithStage(pth, localStage)
+	}
+
+	base, _ := o.stageContent(1, pth)
+	dir, err := os.MkdirTemp("", "kick-union-")
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if removeErr := os.RemoveAll(dir); removeErr != nil && o.Debug {
+			log.Println(removeErr)
+		}
+	}()
+
+	localPath := filepath.Join(dir, "local")
+	basePath := filepath.Join(dir, "base")
+	remotePath := filepath.Join(dir, "remote")
+
+	for p, content := range map[string][]byte{localPath: local, basePath: base, remotePath: remote} {
+		if err = os.WriteFile(p, content, 0600); err != nil {
+			return err
+		}
+	}
+
+	result, err := o.git(StepPull, "merge-file", "-p", "--union", localPath, basePath, remotePath)
+
+	if err != nil {
+		return err
+	}
+
+	if err = o.writeWorkingFile(pth, result.Stdout); err != nil {
+		return err
+	}
+
+	_, err = o.git(StepPull, "add", "--", pth)
+	return err
+}
+
+// applyMergeRules resolves conflicting paths matching MergeRules,
+// yielding the remaining unresolved paths.
+func (o Config) applyMergeRules(paths []string) ([]string, error) {
+	var remaining []string
+
+	for _, pth := range paths {
+		strategy, ok := o.mergeStrategy(pth)
+
+		if !ok {
+			remaining = append(remaining, pth)
+			continue
+		}
+
+		localStage, remoteStage := o.conflictStages()
+		var err error
+
+		switch strategy {
+		case MergeStrategyOurs:
+			err = o.resolveWithStage(pth, localStage)
+		case MergeStrategyTheirs:
+			err = o.resolveWithStage(pth, remoteStage)
+		case MergeStrategyUnion:
+			err = o.resolveUnion(pth)
+		case MergeStrategyNewest:
+			err = o.resolveNewest(pth)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if o.Debug {
+			log.Printf("merge rule: resolved %s with %s\n", pth, strategy)
+		}
+	}
+
+	return remaining, nil
+}
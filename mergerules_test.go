@@ -0,0 +1,140 @@
+package kick
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestValidateMergeRules(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		rules []MergeRule
+		valid bool
+	}{
+		{"none", nil, true},
+		{"supported", []MergeRule{{Path: "*.csv", Strategy: MergeStrategyUnion}}, true},
+		{"missing path", []MergeRule{{Strategy: MergeStrategyOurs}}, false},
+		{"unsupported strategy", []MergeRule{{Path: "*.csv", Strategy: "mine"}}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := validateMergeRules(tc.rules); (err == nil) != tc.valid {
+				t.Errorf("got %v, expected valid %v", err, tc.valid)
+			}
+		})
+	}
+}
+
+func TestApplyMergeRules(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		strategy  string
+		markers   []string
+		responses []fakeResponse
+		expected  string
+		called    []string
+	}{
+		{
+			name:     "ours",
+			strategy: MergeStrategyOurs,
+			expected: "local\n",
+		},
+		{
+			name:     "theirs",
+			strategy: MergeStrategyTheirs,
+			expected: "remote\n",
+		},
+		{
+			name:     "ours while rebasing",
+			strategy: MergeStrategyOurs,
+			markers:  []string{"rebase-merge"},
+			expected: "remote\n",
+		},
+		{
+			name:      "union",
+			strategy:  MergeStrategyUnion,
+			responses: []fakeResponse{{prefix: "merge-file -p --union", stdout: "local\nremote\n"}},
+			expected:  "local\nremote\n",
+			called:    []string{"show :1:a.csv", "merge-file -p --union"},
+		},
+		{
+			name:     "newest local",
+			strategy: MergeStrategyNewest,
+			responses: []fakeResponse{
+				{prefix: "log -1 --format=%ct HEAD -- a.csv", stdout: "200\n"},
+				{prefix: "log -1 --format=%ct MERGE_HEAD -- a.csv", stdout: "100\n"},
+			},
+			expected: "local\n",
+		},
+		{
+			name:     "newest remote",
+			strategy: MergeStrategyNewest,
+			responses: []fakeResponse{
+				{prefix: "log -1 --format=%ct HEAD -- a.csv", stdout: "100\n"},
+				{prefix: "log -1 --format=%ct MERGE_HEAD -- a.csv", stdout: "200\n"},
+			},
+			expected: "remote\n",
+		},
+		{
+			name:     "newest tie",
+			strategy: MergeStrategyNewest,
+			expected: "remote\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			config, runner := fakeConfig(t, stageResponses("a.csv", "local\n", "remote\n")...)
+			runner.responses = append(runner.responses, gitPathResponses(t, config.Dir, tc.markers...)...)
+			runner.responses = append(runner.responses, tc.responses...)
+			config.MergeRules = []MergeRule{{Path: "*.csv", Strategy: tc.strategy}}
+			remaining, err := config.applyMergeRules([]string{"a.csv", "b.txt"})
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if expected := []string{"b.txt"}; !slices.Equal(remaining, expected) {
+				t.Errorf("remaining: got %q, expected %q", remaining, expected)
+			}
+
+			if content := readWorkingFile(t, config.Dir, "a.csv"); content != tc.expected {
+				t.Errorf("got %q, expected %q", content, tc.expected)
+			}
+
+			for _, prefix := range append(tc.called, "add -- a.csv") {
+				if !runner.called(prefix) {
+					t.Errorf("expected git %s, calls: %q", prefix, runner.calls)
+				}
+			}
+		})
+	}
+}
+
+func TestResolveUnionDeletedSide(t *testing.T) {
+	config, runner := fakeConfig(t, stageResponses("a.csv", "", "remote\n")...)
+
+	if err := config.resolveUnion("a.csv"); err != nil {
+		t.Fatal(err)
+	}
+
+	if content := readWorkingFile(t, config.Dir, "a.csv"); content != "remote\n" {
+		t.Errorf("got %q, expected the remaining side", content)
+	}
+
+	if runner.called("merge-file") {
+		t.Errorf("unexpected merge, calls: %q", runner.calls)
+	}
+}
+
+func TestHandlePullConflictMergeRules(t *testing.T) {
+	config, runner := fakeConfig(t, stageResponses("a.csv", "local\n", "remote\n")...)
+	runner.responses = append(runner.responses, gitPathResponses(t, config.Dir, "MERGE_HEAD")...)
+	runner.responses = append(runner.responses, fakeResponse{prefix: "diff --name-only -z --diff-filter=U", stdout: "a.csv\x00"})
+	config.MergeRules = []MergeRule{{Path: "*.csv", Strategy: MergeStrategyTheirs}}
+
+	if err := config.handlePullConflict(MergeConflictError{GitError{Step: StepPull}}, "before"); err != nil {
+		t.Fatal(err)
+	}
+
+	if !runner.called("commit --no-edit") || runner.called("merge --abort") {
+		t.Errorf("expected the merge to complete, calls: %q", runner.calls)
+	}
+}
@@ -0,0 +1,486 @@
+package kick
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Rename describes a renamed file.
+type Rename struct {
+	// From denotes the original path.
+	From string
+
+	// To denotes the new path.
+	To string
+}
+
+// String renders a rename as "from -> to".
+func (o Rename) String() string {
+	return fmt.Sprintf("%s -> %s", o.From, o.To)
+}
+
+// MessageContext exposes repository context to commit message templates.
+type MessageContext struct {
+	// Host denotes the machine hostname.
+	Host string
+
+	// User denotes the current username.
+	User string
+
+	// Branch names the checked out branch, blank when detached.
+	Branch string
+
+	// Time denotes the current local time.
+	Time time.Time
+
+	// UTC denotes the current time in UTC.
+	UTC time.Time
+
+	// Version denotes the kick version.
+	Version string
+
+	// Files lists every changed path.
+	Files []string
+
+	// Added lists new paths.
+	Added []string
+
+	// Modified lists changed paths.
+	Modified []string
+
+	// Deleted lists removed paths.
+	Deleted []string
+
+	// Renamed lists moved paths.
+	Renamed []Rename
+}
+
+// commitMessageFuncs extends the text/template builtins available to commit message templates.
+var commitMessageFuncs = template.FuncMap{
+	"join": strings.Join,
+}
+
+// parseCommitMessage compiles a commit message template.
+func parseCommitMessage(message string) (*template.Template, error) {
+	return template.New("commit_message").Funcs(commitMessageFuncs).Parse(message)
+}
+
+// validateCommitMessage rejects malformed commit message templates.
+func validateCommitMessage(message string) error {
+	if _, err := parseCommitMessage(message); err != nil {
+		return fmt.Errorf("commit message: %v", err)
+	}
+
+	return nil
+}
+
+// currentUser queries the current username.
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+
+	return os.Getenv("USERNAME")
+}
+
+// messageContext gathers repository context for commit message templates,
+// covering the given changes, such as from stagedChanges.
+func (o Config) messageContext(t time.Time, changes []statusEntry) (MessageContext, error) {
+	host, err := os.Hostname()
+
+	if err != nil {
+		return MessageContext{}, err
+	}
+
+	context := MessageContext{
+		Host:    host,
+		User:    currentUser(),
+		Branch:  o.currentBranch(),
+		Time:    t.Local(),
+		UTC:     t.UTC(),
+		Version: Version,
+	}
+
+	for _, entry := range changes {
+		context.Files = append(context.Files, entry.Path)
+		code := entry.Index
+
+		if code == ' ' || code == '?' {
+			code = entry.Worktree
+		}
+
+		switch code {
+		case '?', 'A', 'C':
+			context.Added = append(context.Added, entry.Path)
+		case 'D':
+			context.Deleted = append(context.Deleted, entry.Path)
+		case 'R':
+			context.Renamed = append(context.Renamed, Rename{From: entry.OrigPath, To: entry.Path})
+		default:
+			context.Modified = append(context.Modified, entry.Path)
+		}
+	}
+
+	return context, nil
+}
+
+// renderCommitMessage evaluates the CommitMessage template against the given changes.
+func (o Config) renderCommitMessage(changes []statusEntry) (string, error) {
+	tmpl, err := parseCommitMessage(o.CommitMessage)
+
+	if err != nil {
+		return "", err
+	}
+
+	context, err := o.messageContext(time.Now(), changes)
+
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+
+	if err = tmpl.Execute(&b, context); err != nil {
+		return "", fmt.Errorf("commit message: %v", err)
+	}
+
+	return b.String(), nil
+}
+
+// MessageModeTemplate renders CommitMessage as a template.
+const MessageModeTemplate = "template"
+
+// MessageModeAuto summarizes the staged changes, with a diffstat body.
+const MessageModeAuto = "auto"
+
+// MessageModeFile reads commit messages from MessageFile, clearing it after each successful commit.
+const MessageModeFile = "file"
+
+// MessageModeCommand reads commit messages from the standard output of MessageCommand.
+const MessageModeCommand = "command"
+
+// MessageModes lists the supported message modes.
+var MessageModes = []string{
+	MessageModeTemplate,
+	MessageModeAuto,
+	MessageModeFile,
+	MessageModeCommand,
+}
+
+// DefaultSubjectLimit denotes the default maximum length of generated commit subjects.
+const DefaultSubjectLimit = 72
+
+// validateMessageMode rejects unsupported message modes.
+func validateMessageMode(mode string) error {
+	if !slices.Contains(MessageModes, mode) {
+		return fmt.Errorf("unsupported message mode %q, expected one of %v", mode, MessageModes)
+	}
+
+	return nil
+}
+
+// validateSubjectLimit rejects nonpositive subject lengths.
+func validateSubjectLimit(limit int) error {
+	if limit < 1 {
+		return fmt.Errorf("invalid subject limit %d, expected a positive length", limit)
+	}
+
+	return nil
+}
+
+// joinWords renders a list in prose, as "a, b and c".
+func joinWords(items []string) string {
+	if len(items) < 2 {
+		return strings.Join(items, "")
+	}
+
+	return fmt.Sprintf("%s and %s", strings.Join(items[:len(items)-1], ", "), items[len(items)-1])
+}
+
+// pluralize renders a count of things, as "1 file" or "2 files".
+func pluralize(n int, singular string, plural string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, singular)
+	}
+
+	return fmt.Sprintf("%d %s", n, plural)
+}
+
+// capitalize uppercases the first letter of a sentence.
+func capitalize(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+
+	if size == 0 {
+		return s
+	}
+
+	return string(unicode.ToUpper(r)) + s[size:]
+}
+
+// describeChanges summarizes changes file by file,
+// as "Update README.md, add docs/setup.md, remove old.txt".
+func describeChanges(context MessageContext) string {
+	var renames []string
+
+	for _, rename := range context.Renamed {
+		renames = append(renames, fmt.Sprintf("%s to %s", rename.From, rename.To))
+	}
+
+	groups := []struct {
+		verb  string
+		paths []string
+	}{
+		{"update", context.Modified},
+		{"add", context.Added},
+		{"remove", context.Deleted},
+		{"rename", renames},
+	}
+
+	var clauses []string
+
+	for _, group := range groups {
+		if len(group.paths) != 0 {
+			clauses = append(clauses, fmt.Sprintf("%s %s", group.verb, joinWords(group.paths)))
+		}
+	}
+
+	return capitalize(strings.Join(clauses, ", "))
+}
+
+// topDirectory identifies the top level directory containing a path, as "docs/",
+// or "./" for files at the top level.
+func topDirectory(pth string) string {
+	dir, _, found := strings.Cut(pth, "/")
+
+	if !found {
+		return "./"
+	}
+
+	return dir + "/"
+}
+
+// countDirectories tallies paths per top level directory, yielding the sorted directories and their counts.
+func countDirectories(paths []string) ([]string, map[string]int) {
+	counts := make(map[string]int)
+	var dirs []string
+
+	for _, pth := range paths {
+		dir := topDirectory(pth)
+
+		if counts[dir] == 0 {
+			dirs = append(dirs, dir)
+		}
+
+		counts[dir]++
+	}
+
+	slices.Sort(dirs)
+	return dirs, counts
+}
+
+// rollupChanges summarizes changes per top level directory,
+// as "Update docs/ (5 files) and src/ (2 files)".
+func rollupChanges(context MessageContext) string {
+	dirs, counts := countDirectories(context.Files)
+	var items []string
+
+	for _, dir := range dirs {
+		items = append(items, fmt.Sprintf("%s (%s)", dir, pluralize(counts[dir], "file", "files")))
+	}
+
+	return fmt.Sprintf("Update %s", joinWords(items))
+}
+
+// truncate shortens a string to a maximum number of characters, marking any elision.
+func truncate(s string, limit int) string {
+	runes := []rune(s)
+
+	if len(runes) <= limit {
+		return s
+	}
+
+	if limit <= 3 {
+		return string(runes[:limit])
+	}
+
+	return string(runes[:limit-3]) + "..."
+}
+
+// summarizeChanges composes a commit subject within a length limit,
+// falling back from file by file descriptions to per directory rollups to bare counts.
+func summarizeChanges(context MessageContext, limit int) string {
+	for _, subject := range []string{describeChanges(context), rollupChanges(context)} {
+		if utf8.RuneCountInString(subject) <= limit {
+			return subject
+		}
+	}
+
+	dirs, _ := countDirectories(context.Files)
+	subject := fmt.Sprintf(
+		"Update %s in %s",
+		pluralize(len(context.Files), "file", "files"),
+		pluralize(len(dirs), "directory", "directories"),
+	)
+
+	return truncate(subject, limit)
+}
+
+// autoCommitMessage summarizes the given changes, with a diffstat body of the staged changes.
+//
+// Falls back to the CommitMessage template when nothing changed, such as for empty nonce commits.
+func (o Config) autoCommitMessage(changes []statusEntry) (string, error) {
+	context, err := o.messageContext(time.Now(), changes)
+
+	if err != nil {
+		return "", err
+	}
+
+	if len(context.Files) == 0 {
+		return o.renderCommitMessage(changes)
+	}
+
+	result, err := o.git(StepCommit, "diff", "--cached", "--stat")
+
+	if err != nil {
+		return "", err
+	}
+
+	subject := summarizeChanges(context, o.SubjectLimit)
+	diffstat := strings.TrimRight(string(result.Stdout), "\n")
+
+	if diffstat == "" {
+		return subject, nil
+	}
+
+	return fmt.Sprintf("%s\n\n%s", subject, diffstat), nil
+}
+
+// validateMessageSource rejects message modes lacking their source.
+func (o Config) validateMessageSource() error {
+	switch {
+	case o.MessageMode == MessageModeFile && o.MessageFile == "":
+		return fmt.Errorf("message mode %s requires a message file", MessageModeFile)
+	case o.MessageMode == MessageModeCommand && o.MessageCommand == "":
+		return fmt.Errorf("message mode %s requires a message command", MessageModeCommand)
+	}
+
+	return nil
+}
+
+// messageFileRelPath resolves MessageFile as a slash separated, repository relative path,
+// yielding a blank string outside of MessageModeFile.
+func (o Config) messageFileRelPath() string {
+	if o.MessageMode != MessageModeFile || o.MessageFile == "" {
+		return ""
+	}
+
+	return filepath.ToSlash(filepath.Clean(filepath.FromSlash(o.MessageFile)))
+}
+
+// fileCommitMessage reads the commit message from MessageFile.
+//
+// Falls back to the CommitMessage template when the file is missing or blank.
+func (o Config) fileCommitMessage(changes []statusEntry) (string, error) {
+	var content []byte
+
+	err := o.inRepository(func(root *os.Root) error {
+		var readErr error
+		content, readErr = root.ReadFile(filepath.FromSlash(o.MessageFile))
+		return readErr
+	})
+
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+
+	if message := strings.TrimSpace(string(content)); message != "" {
+		return message, nil
+	}
+
+	if o.Debug {
+		log.Printf("message: %s blank, falling back to commit message\n", o.MessageFile)
+	}
+
+	return o.renderCommitMessage(changes)
+}
+
+// clearMessageFile empties MessageFile, so that each prepared message applies to a single commit.
+func (o Config) clearMessageFile() error {
+	return o.inRepository(func(root *os.Root) error {
+		f, err := root.OpenFile(filepath.FromSlash(o.MessageFile), os.O_WRONLY|os.O_TRUNC, 0)
+
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		return f.Close()
+	})
+}
+
+// commandCommitMessage reads the commit message from the standard output of MessageCommand,
+// which receives the given changed paths on standard input, one per line.
+//
+// Falls back to the CommitMessage template when the command fails or prints nothing.
+func (o Config) commandCommitMessage(changes []statusEntry) (string, error) {
+	context, err := o.messageContext(time.Now(), changes)
+
+	if err != nil {
+		return "", err
+	}
+
+	var stdout bytes.Buffer
+	cmd := o.shell(o.MessageCommand)
+	cmd.Stdin = strings.NewReader(strings.Join(append(context.Files, ""), "\n"))
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+
+	if o.Debug {
+		log.Printf("cmd: %v\n", cmd)
+	}
+
+	if err = cmd.Run(); err != nil {
+		log.Printf("message: %s: %v, falling back to commit message\n", o.MessageCommand, err)
+		return o.renderCommitMessage(changes)
+	}
+
+	if message := strings.TrimSpace(stdout.String()); message != "" {
+		return message, nil
+	}
+
+	log.Printf("message: %s printed nothing, falling back to commit message\n", o.MessageCommand)
+	return o.renderCommitMessage(changes)
+}
+
+// commitMessage composes a commit message per MessageMode, describing the given changes.
+func (o Config) commitMessage(changes []statusEntry) (string, error) {
+	switch o.MessageMode {
+	case MessageModeAuto:
+		return o.autoCommitMessage(changes)
+	case MessageModeFile:
+		return o.fileCommitMessage(changes)
+	case MessageModeCommand:
+		return o.commandCommitMessage(changes)
+	default:
+		return o.renderCommitMessage(changes)
+	}
+}
@@ -0,0 +1,348 @@
+package kick
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestMessageContext(t *testing.T) {
+	config, _ := fakeConfig(t)
+	context, err := config.messageContext(time.Now(), []statusEntry{
+		{Index: 'M', Worktree: ' ', Path: "m.txt"},
+		{Index: 'A', Worktree: ' ', Path: "a.txt"},
+		{Index: 'D', Worktree: ' ', Path: "d.txt"},
+		{Index: 'R', Worktree: ' ', Path: "to.txt", OrigPath: "from.txt"},
+		{Index: '?', Worktree: '?', Path: "new.txt"},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := MessageContext{
+		Files:    []string{"m.txt", "a.txt", "d.txt", "to.txt", "new.txt"},
+		Modified: []string{"m.txt"},
+		Added:    []string{"a.txt", "new.txt"},
+		Deleted:  []string{"d.txt"},
+		Renamed:  []Rename{{From: "from.txt", To: "to.txt"}},
+	}
+
+	for _, field := range []string{"Files", "Modified", "Added", "Deleted", "Renamed"} {
+		got := reflect.ValueOf(context).FieldByName(field).Interface()
+		want := reflect.ValueOf(expected).FieldByName(field).Interface()
+
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %q, expected %q", field, got, want)
+		}
+	}
+}
+
+func TestRenderCommitMessage(t *testing.T) {
+	config, _ := fakeConfig(t, fakeResponse{prefix: "symbolic-ref --quiet --short HEAD", stdout: "main\n"})
+	config.CommitMessage = `{{.Branch}}: {{join .Files ", "}}`
+	message, err := config.renderCommitMessage([]statusEntry{
+		{Index: 'M', Worktree: ' ', Path: "a.txt"},
+		{Index: 'A', Worktree: ' ', Path: "b.txt"},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "main: a.txt, b.txt"; message != expected {
+		t.Errorf("got %q, expected %q", message, expected)
+	}
+}
+
+func TestValidateCommitMessage(t *testing.T) {
+	for _, tc := range []struct {
+		message string
+		valid   bool
+	}{
+		{"up", true},
+		{"{{.Host}} {{.UTC.Format \"2006-01-02\"}}", true},
+		{"{{.Host", false},
+		{"{{nope}}", false},
+	} {
+		t.Run(tc.message, func(t *testing.T) {
+			if err := validateCommitMessage(tc.message); (err == nil) != tc.valid {
+				t.Errorf("got %v, expected valid %v", err, tc.valid)
+			}
+		})
+	}
+}
+
+func TestJoinWords(t *testing.T) {
+	for _, tc := range []struct {
+		items    []string
+		expected string
+	}{
+		{nil, ""},
+		{[]string{"a"}, "a"},
+		{[]string{"a", "b"}, "a and b"},
+		{[]string{"a", "b", "c"}, "a, b and c"},
+	} {
+		t.Run(tc.expected, func(t *testing.T) {
+			if s := joinWords(tc.items); s != tc.expected {
+				t.Errorf("got %q, expected %q", s, tc.expected)
+			}
+		})
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	for _, tc := range []struct {
+		s        string
+		limit    int
+		expected string
+	}{
+		{"héllo", 10, "héllo"},
+		{"héllo", 5, "héllo"},
+		{"héllo wörld", 8, "héllo..."},
+		{"abcdef", 3, "abc"},
+	} {
+		t.Run(tc.s, func(t *testing.T) {
+			if s := truncate(tc.s, tc.limit); s != tc.expected {
+				t.Errorf("got %q, expected %q", s, tc.expected)
+			}
+		})
+	}
+}
+
+func TestSummarizeChanges(t *testing.T) {
+	context := MessageContext{
+		Files:    []string{"README.md", "docs/setup.md", "old.txt", "a.txt"},
+		Modified: []string{"README.md"},
+		Added:    []string{"docs/setup.md"},
+		Deleted:  []string{"old.txt"},
+		Renamed:  []Rename{{From: "b.txt", To: "a.txt"}},
+	}
+
+	for _, tc := range []struct {
+		limit    int
+		expected string
+	}{
+		{80, "Update README.md, add docs/setup.md, remove old.txt, rename b.txt to a.txt"},
+		{40, "Update ./ (3 files) and docs/ (1 file)"},
+		{31, "Update 4 files in 2 directories"},
+		{20, "Update 4 files in..."},
+	} {
+		t.Run(tc.expected, func(t *testing.T) {
+			if s := summarizeChanges(context, tc.limit); s != tc.expected {
+				t.Errorf("got %q, expected %q", s, tc.expected)
+			}
+		})
+	}
+}
+
+func TestAutoCommitMessage(t *testing.T) {
+	config, _ := fakeConfig(t, fakeResponse{prefix: "diff --cached --stat", stdout: " a.txt | 1 +\n 1 file changed, 1 insertion(+)\n"})
+	config.MessageMode = MessageModeAuto
+	message, err := config.commitMessage([]statusEntry{{Index: 'A', Worktree: ' ', Path: "a.txt"}})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "Add a.txt\n\n a.txt | 1 +\n 1 file changed, 1 insertion(+)"; message != expected {
+		t.Errorf("got %q, expected %q", message, expected)
+	}
+}
+
+func TestAutoCommitMessageWithoutChanges(t *testing.T) {
+	config, _ := fakeConfig(t)
+	config.MessageMode = MessageModeAuto
+	message, err := config.commitMessage(nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if message != DefaultCommitMessage {
+		t.Errorf("got %q, expected %q", message, DefaultCommitMessage)
+	}
+}
+
+func TestKickMessageFile(t *testing.T) {
+	config, runner := fakeConfig(t, fakeResponse{prefix: "diff --cached --name-status", stdout: "M\x00a.txt\x00"})
+	writeWorkingFiles(t, config.Dir, map[string]string{"MESSAGE": "  prepared message\n\n"})
+	config.MessageMode = MessageModeFile
+	config.MessageFile = "MESSAGE"
+
+	if err := config.Kick(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !runner.called("commit -m prepared message") {
+		t.Errorf("expected the prepared message, calls: %q", runner.calls)
+	}
+
+	if content := readWorkingFile(t, config.Dir, "MESSAGE"); content != "" {
+		t.Errorf("expected the message file to be emptied, got %q", content)
+	}
+
+	if runner.called("add .") {
+		t.Errorf("expected selective staging around the message file, calls: %q", runner.calls)
+	}
+}
+
+func TestFileCommitMessageFallback(t *testing.T) {
+	for _, content := range []string{"", " \n"} {
+		t.Run(content, func(t *testing.T) {
+			config, _ := fakeConfig(t)
+			config.MessageMode = MessageModeFile
+			config.MessageFile = "MESSAGE"
+
+			if content != "" {
+				writeWorkingFiles(t, config.Dir, map[string]string{"MESSAGE": content})
+			}
+
+			message, err := config.commitMessage(nil)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if message != DefaultCommitMessage {
+				t.Errorf("got %q, expected %q", message, DefaultCommitMessage)
+			}
+		})
+	}
+}
+
+func TestMessageFileRelPath(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, tc := range []struct {
+		name     string
+		mode     string
+		file     string
+		expected string
+	}{
+		{"relative", MessageModeFile, "notes/MESSAGE", "notes/MESSAGE"},
+		{"unclean", MessageModeFile, "./notes/../MESSAGE", "MESSAGE"},
+		{"other mode", MessageModeTemplate, "MESSAGE", ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			config := NewConfig()
+			config.Dir = dir
+			config.MessageMode = tc.mode
+			config.MessageFile = tc.file
+
+			if pth := config.messageFileRelPath(); pth != tc.expected {
+				t.Errorf("got %q, expected %q", pth, tc.expected)
+			}
+		})
+	}
+}
+
+func TestValidateMessageFile(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		file  string
+		valid bool
+	}{
+		{"relative", "notes/MESSAGE", true},
+		{"absolute", filepath.Join(t.TempDir(), "MESSAGE"), false},
+		{"parent", "../MESSAGE", false},
+		{"escaping", "notes/../../MESSAGE", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			config := NewConfig()
+			config.MessageMode = MessageModeFile
+			config.MessageFile = tc.file
+
+			if err := config.Validate(); (err == nil) != tc.valid {
+				t.Errorf("got %v, expected valid %v", err, tc.valid)
+			}
+		})
+	}
+}
+
+func TestFileCommitMessageSymlinkEscape(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symbolic links require privileges")
+	}
+
+	config, _ := fakeConfig(t)
+	outside := filepath.Join(t.TempDir(), "secret.txt")
+
+	if err := os.WriteFile(outside, []byte("secret\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Symlink(outside, filepath.Join(config.Dir, "MESSAGE")); err != nil {
+		t.Fatal(err)
+	}
+
+	config.MessageMode = MessageModeFile
+	config.MessageFile = "MESSAGE"
+
+	if message, err := config.fileCommitMessage(nil); err == nil {
+		t.Errorf("expected a refusal to read outside the repository, got %q", message)
+	}
+
+	if err := config.clearMessageFile(); err == nil {
+		t.Error("expected a refusal to empty a file outside the repository")
+	}
+
+	if content, err := os.ReadFile(outside); err != nil || string(content) != "secret\n" {
+		t.Errorf("expected the outside file to remain intact, got %q, %v", content, err)
+	}
+}
+
+func TestCommandCommitMessage(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("message command fixtures use sh")
+	}
+
+	changes := []statusEntry{{Index: 'M', Worktree: ' ', Path: "a.txt"}, {Index: 'A', Worktree: ' ', Path: "b.txt"}}
+
+	for _, tc := range []struct {
+		name     string
+		command  string
+		expected string
+	}{
+		{"output", "echo '  from command  '", "from command"},
+		{"staged paths on standard input", "tr '\\n' ' '", "a.txt b.txt"},
+		{"failure", "echo partial; exit 3", DefaultCommitMessage},
+		{"no output", "true", DefaultCommitMessage},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			config, _ := fakeConfig(t)
+			config.MessageMode = MessageModeCommand
+			config.MessageCommand = tc.command
+			message, err := config.commitMessage(changes)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if message != tc.expected {
+				t.Errorf("got %q, expected %q", message, tc.expected)
+			}
+		})
+	}
+}
+
+func TestCommandCommitMessageRunsInRepository(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("message command fixtures use sh")
+	}
+
+	config, _ := fakeConfig(t)
+	config.MessageMode = MessageModeCommand
+	config.MessageCommand = "touch ran"
+
+	if _, err := config.commitMessage(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(config.Dir, "ran")); err != nil {
+		t.Errorf("expected the command to run in the repository: %v", err)
+	}
+}
@@ -0,0 +1,91 @@
+package kick
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"time"
+)
+
+// NonceModeFile writes timestamps to a single shared file at NoncePath.
+//
+// Pulls resolve conflicting nonce files with MergeStrategyNewest.
+const NonceModeFile = "file"
+
+// NonceModeHost writes timestamps to a per-host file beneath a NoncePath directory,
+// so that machines never edit the same file.
+const NonceModeHost = "host"
+
+// NonceModeEmpty generates empty commits, without any nonce file.
+const NonceModeEmpty = "empty"
+
+// NonceModes lists the supported nonce modes.
+var NonceModes = []string{
+	NonceModeFile,
+	NonceModeHost,
+	NonceModeEmpty,
+}
+
+// DefaultNonceFormat denotes the default time layout of nonce timestamps.
+const DefaultNonceFormat = time.RFC3339
+
+// validateNonceMode rejects unsupported nonce modes.
+func validateNonceMode(mode string) error {
+	if !slices.Contains(NonceModes, mode) {
+		return fmt.Errorf("unsupported nonce mode %q, expected one of %v", mode, NonceModes)
+	}
+
+	return nil
+}
+
+// nonceBasePath resolves NoncePath, defaulting per NonceMode.
+func (o Config) nonceBasePath() string {
+	switch {
+	case o.NoncePath != "":
+		return o.NoncePath
+	case o.NonceMode == NonceModeHost:
+		return NonceHostPath
+	default:
+		return NoncePath
+	}
+}
+
+// noncePath resolves the repository relative nonce file,
+// yielding a blank string in NonceModeEmpty.
+func (o Config) noncePath() (string, error) {
+	switch o.NonceMode {
+	case NonceModeEmpty:
+		return "", nil
+	case NonceModeHost:
+		host, err := os.Hostname()
+
+		if err != nil {
+			return "", err
+		}
+
+		return path.Join(o.nonceBasePath(), host), nil
+	default:
+		return o.nonceBasePath(), nil
+	}
+}
+
+// EnsureNonce updates the nonce file with the current timestamp, according to NonceMode.
+func (o Config) EnsureNonce() error {
+	pth, err := o.noncePath()
+
+	if err != nil || pth == "" {
+		return err
+	}
+
+	return o.writeWorkingFile(pth, []byte(time.Now().UTC().Format(o.NonceFormat)))
+}
+
+// mergeRules lists MergeRules, followed by any built-in rule for the shared nonce file.
+func (o Config) mergeRules() []MergeRule {
+	if !o.Nonce || o.NonceMode != NonceModeFile {
+		return o.MergeRules
+	}
+
+	return append(slices.Clone(o.MergeRules), MergeRule{Path: "/" + o.nonceBasePath(), Strategy: MergeStrategyNewest})
+}
@@ -0,0 +1,131 @@
+package kick
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNonceBasePath(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		mode      string
+		noncePath string
+		expected  string
+	}{
+		{"file", NonceModeFile, "", NoncePath},
+		{"host", NonceModeHost, "", NonceHostPath},
+		{"custom", NonceModeHost, "sync", "sync"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			config := NewConfig()
+			config.NonceMode = tc.mode
+			config.NoncePath = tc.noncePath
+
+			if pth := config.nonceBasePath(); pth != tc.expected {
+				t.Errorf("got %q, expected %q", pth, tc.expected)
+			}
+		})
+	}
+}
+
+func TestKickRejectsEscapingNoncePath(t *testing.T) {
+	for _, noncePath := range []string{"../victim", "sync/../../victim", "/tmp/victim"} {
+		t.Run(noncePath, func(t *testing.T) {
+			config, runner := fakeConfig(t)
+			config.Nonce = true
+			config.NoncePath = noncePath
+
+			if code := ExitCode(config.Kick()); code != ExitUsage {
+				t.Errorf("got exit code %d, expected %d", code, ExitUsage)
+			}
+
+			if len(runner.calls) != 0 {
+				t.Errorf("unexpected git calls: %q", runner.calls)
+			}
+
+			if _, err := os.Stat(filepath.Join(filepath.Dir(config.Dir), "victim")); err == nil {
+				t.Error("nonce written outside the repository")
+			}
+		})
+	}
+}
+
+func TestMergeRules(t *testing.T) {
+	custom := MergeRule{Path: "*.csv", Strategy: MergeStrategyNewest}
+
+	for _, tc := range []struct {
+		name     string
+		nonce    bool
+		mode     string
+		expected []MergeRule
+	}{
+		{"without nonce", false, NonceModeFile, []MergeRule{custom}},
+		{"file nonce", true, NonceModeFile, []MergeRule{custom, {Path: "/.kick", Strategy: MergeStrategyNewest}}},
+		{"host nonce", true, NonceModeHost, []MergeRule{custom}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			config := NewConfig()
+			config.Nonce = tc.nonce
+			config.NonceMode = tc.mode
+			config.MergeRules = []MergeRule{custom}
+
+			if rules := config.mergeRules(); !reflect.DeepEqual(rules, tc.expected) {
+				t.Errorf("got %+v, expected %+v", rules, tc.expected)
+			}
+
+			if len(config.MergeRules) != 1 {
+				t.Errorf("mergeRules modified MergeRules: %+v", config.MergeRules)
+			}
+		})
+	}
+}
+
+func TestEnsureNonce(t *testing.T) {
+	host, err := os.Hostname()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		mode     string
+		expected string
+	}{
+		{"file", NonceModeFile, NoncePath},
+		{"host", NonceModeHost, path.Join(NonceHostPath, host)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			config, _ := fakeConfig(t)
+			config.Nonce = true
+			config.NonceMode = tc.mode
+
+			if err = config.EnsureNonce(); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err = time.Parse(config.NonceFormat, readWorkingFile(t, config.Dir, tc.expected)); err != nil {
+				t.Errorf("expected a timestamp in %s: %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestKickNonceEmpty(t *testing.T) {
+	config, runner := fakeConfig(t)
+	config.Nonce = true
+	config.NonceMode = NonceModeEmpty
+	config.CommitMessage = "up"
+
+	if err := config.Kick(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !runner.called("commit --allow-empty -m up") {
+		t.Errorf("expected an empty commit, calls: %q", runner.calls)
+	}
+}
@@ -0,0 +1,395 @@
+package kick
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Operation describes a single step Kick would perform.
+type Operation struct {
+	// Step names the Kick step, such as StepPull.
+	Step string `json:"step"`
+
+	// Args denotes git arguments, when the step invokes git.
+	Args []string `json:"args,omitempty"`
+
+	// Description summarizes the operation.
+	Description string `json:"description"`
+}
+
+// TagSync describes tags Kick would exchange with a remote.
+type TagSync struct {
+	// Remote names a git remote.
+	Remote string `json:"remote"`
+
+	// Fetch lists remote tags missing locally.
+	Fetch []string `json:"fetch"`
+
+	// Push lists local tags missing from the remote.
+	Push []string `json:"push"`
+}
+
+// Plan describes what Kick would do, without performing any changes.
+//
+// Lists are empty rather than nil, so that JSON renders them as arrays.
+type Plan struct {
+	// Files lists paths that would be staged.
+	Files []string `json:"files"`
+
+	// Commit reports whether a commit would be made.
+	Commit bool `json:"commit"`
+
+	// CommitMessage denotes the commit message, if any.
+	CommitMessage string `json:"commit_message"`
+
+	// PullRemotes lists remotes that would be pulled.
+	PullRemotes []string `json:"pull_remotes"`
+
+	// PushRemotes lists remotes that would be pushed.
+	PushRemotes []string `json:"push_remotes"`
+
+	// Tags lists tag exchanges per remote.
+	Tags []TagSync `json:"tags"`
+
+	// Operations lists the full sequence of operations, in order.
+	Operations []Operation `json:"operations"`
+}
+
+// String renders a human readable plan.
+func (o Plan) String() string {
+	var b strings.Builder
+
+	b.WriteString("stage:\n")
+
+	for _, file := range o.Files {
+		fmt.Fprintf(&b, "  %s\n", file)
+	}
+
+	if o.Commit {
+		fmt.Fprintf(&b, "commit: %q\n", o.CommitMessage)
+	} else {
+		b.WriteString("commit: none\n")
+	}
+
+	fmt.Fprintf(&b, "pull: %s\n", strings.Join(o.PullRemotes, ", "))
+	fmt.Fprintf(&b, "push: %s\n", strings.Join(o.PushRemotes, ", "))
+	b.WriteString("tags:\n")
+
+	for _, tagSync := range o.Tags {
+		fmt.Fprintf(
+			&b,
+			"  %s: fetch [%s] push [%s]\n",
+			tagSync.Remote,
+			strings.Join(tagSync.Fetch, ", "),
+			strings.Join(tagSync.Push, ", "),
+		)
+	}
+
+	b.WriteString("operations:\n")
+
+	for _, operation := range o.Operations {
+		fmt.Fprintf(&b, "  %s\n", operation.Description)
+	}
+
+	return b.String()
+}
+
+// gitOperation describes a git invocation.
+func gitOperation(step string, args []string) Operation {
+	return Operation{
+		Step:        step,
+		Args:        args,
+		Description: fmt.Sprintf("git %s", strings.Join(args, " ")),
+	}
+}
+
+// missing lists the elements of xs absent from ys.
+func missing(xs []string, ys []string) []string {
+	zs := []string{}
+
+	for _, x := range xs {
+		if !slices.Contains(ys, x) {
+			zs = append(zs, x)
+		}
+	}
+
+	return zs
+}
+
+// commandMessagePlaceholder stands in for the output of MessageCommand in plans,
+// as planning never runs commands.
+const commandMessagePlaceholder = "<output of message_command>"
+
+// planGuards predicts the safety guards applied between staging and committing,
+// yielding the files left staged along with the guard operations.
+//
+// Reports the error Kick would, when a guard would refuse to commit.
+func (o Config) planGuards(files []string, changes []statusEntry) ([]string, []Operation, error) {
+	var operations []Operation
+
+	if o.sizeLimited() {
+		operations = append(operations, Operation{Step: StepSizes, Description: "check staged file sizes"})
+		worktree, err := o.worktreeFiles(files)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if violations := o.sizeViolations(worktree); len(violations) != 0 {
+			if o.SizePolicy != SizePolicyUnstage {
+				return nil, nil, SizeError{Violations: violations}
+			}
+
+			var unstage []string
+
+			for _, violation := range violations {
+				unstage = append(unstage, violation.Path)
+			}
+
+			files = slices.DeleteFunc(slices.Clone(files), func(pth string) bool {
+				return slices.Contains(unstage, pth)
+			})
+			operations = append(operations, gitOperation(StepSizes, unstageArgs()))
+		}
+	}
+
+	if o.massChangeLimited() {
+		operations = append(operations, Operation{Step: StepMassChange, Description: "check mass deletion and change limits"})
+		tracked, err := o.trackedCount()
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var deletions, count int
+
+		for _, change := range changes {
+			if !slices.Contains(files, change.Path) {
+				continue
+			}
+
+			count++
+
+			if change.Index == 'D' || change.Worktree == 'D' {
+				deletions++
+			}
+		}
+
+		if err = o.massChangeLimit(deletions, count, tracked); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if o.ScanSecrets {
+		operations = append(operations, Operation{Step: StepSecrets, Description: "scan staged changes for secrets"})
+	}
+
+	return files, operations, nil
+}
+
+// Plan computes the operations Kick would perform, without changing the repository.
+//
+// Plans predict size limits from working tree files, and never run MessageCommand.
+func (o Config) Plan() (Plan, error) {
+	plan := Plan{
+		Files:       []string{},
+		PullRemotes: []string{},
+		PushRemotes: []string{},
+		Tags:        []TagSync{},
+		Operations:  []Operation{},
+	}
+
+	if err := o.Validate(); err != nil {
+		return plan, err
+	}
+
+	if err := o.Preflight(); err != nil {
+		return plan, err
+	}
+
+	if err := o.ResolveDir(); err != nil {
+		return plan, err
+	}
+
+	if err := o.QueryRemotes(); err != nil {
+		return plan, err
+	}
+
+	rules, err := o.stageRules()
+
+	if err != nil {
+		return plan, err
+	}
+
+	files, err := o.selectedPaths()
+
+	if err != nil {
+		return plan, err
+	}
+
+	plan.Operations = append(plan.Operations, hookOperations(HookBeforeStage, o.Hooks.BeforeStage)...)
+
+	if o.Nonce {
+		var noncePath string
+		noncePath, err = o.noncePath()
+
+		if err != nil {
+			return plan, err
+		}
+
+		if noncePath == "" {
+			plan.Operations = append(plan.Operations, Operation{
+				Step:        StepNonce,
+				Description: "allow empty commit",
+			})
+		} else {
+			plan.Operations = append(plan.Operations, Operation{
+				Step:        StepNonce,
+				Description: fmt.Sprintf("write timestamp to %s", noncePath),
+			})
+
+			if !slices.Contains(files, noncePath) {
+				files = append(files, noncePath)
+			}
+		}
+	}
+
+	if rules.active() {
+		var unstage []string
+
+		if _, unstage, err = o.stagePlan(rules); err != nil {
+			return plan, err
+		}
+
+		if len(unstage) != 0 {
+			plan.Operations = append(plan.Operations, gitOperation(StepStage, unstageArgs()))
+		}
+
+		plan.Operations = append(plan.Operations, gitOperation(StepStage, selectiveStageArgs()))
+	} else {
+		plan.Operations = append(plan.Operations, gitOperation(StepStage, o.stageArgs()))
+	}
+
+	changes, err := o.previewChanges(rules)
+
+	if err != nil {
+		return plan, err
+	}
+
+	var guards []Operation
+
+	if files, guards, err = o.planGuards(files, changes); err != nil {
+		return plan, err
+	}
+
+	plan.Files = append(plan.Files, files...)
+	plan.Operations = append(plan.Operations, guards...)
+	plan.Operations = append(plan.Operations, hookOperations(HookBeforeCommit, o.Hooks.BeforeCommit)...)
+	plan.Commit = len(files) != 0 || (o.Nonce && o.NonceMode == NonceModeEmpty)
+
+	if plan.Commit {
+		changes = slices.DeleteFunc(changes, func(change statusEntry) bool {
+			return !slices.Contains(files, change.Path)
+		})
+
+		if o.MessageMode == MessageModeCommand {
+			plan.CommitMessage = commandMessagePlaceholder
+			plan.Operations = append(plan.Operations, Operation{
+				Step:        StepCommit,
+				Description: fmt.Sprintf("run message command: %s", o.MessageCommand),
+			})
+		} else if plan.CommitMessage, err = o.commitMessage(changes); err != nil {
+			return plan, err
+		}
+
+		plan.Operations = append(plan.Operations, gitOperation(StepCommit, o.commitArgs(plan.CommitMessage)))
+	}
+
+	remote, branch, linking := o.upstreamLink()
+
+	switch {
+	case linking:
+		plan.PullRemotes = []string{}
+	case o.PullAll:
+		plan.PullRemotes = append(plan.PullRemotes, o.remotes...)
+	default:
+		plan.PullRemotes = []string{o.upstreamRemote()}
+	}
+
+	if !linking {
+		plan.Operations = append(plan.Operations, gitOperation(StepPull, o.pullArgs()))
+		plan.Operations = append(plan.Operations, hookOperations(HookAfterPull, o.Hooks.AfterPull)...)
+	}
+
+	plan.PushRemotes = []string{o.pushRemote()}
+
+	if linking {
+		plan.PushRemotes = []string{remote}
+		plan.Operations = append(plan.Operations, gitOperation(StepPush, setUpstreamArgs(remote, branch)))
+	}
+
+	if !linking || o.PushAll {
+		plan.Operations = append(plan.Operations, gitOperation(StepPush, o.pushArgs()))
+	}
+
+	plan.Operations = append(plan.Operations, hookOperations(HookAfterPush, o.Hooks.AfterPush)...)
+
+	if !o.SyncTags {
+		return plan, nil
+	}
+
+	plan.Operations = append(plan.Operations, gitOperation(StepFetchTags, o.fetchTagsArgs()))
+
+	for _, args := range o.pushTagsArgs() {
+		plan.Operations = append(plan.Operations, gitOperation(StepPushTags, args))
+	}
+
+	fetchRemotes := []string{o.upstreamRemote()}
+
+	if o.FetchAll {
+		fetchRemotes = o.remotes
+	}
+
+	pushRemotes := []string{o.pushRemote()}
+
+	if o.PushAll {
+		pushRemotes = o.remotes
+	}
+
+	localTags, err := o.gitLines(StepQuery, "tag", "--list")
+
+	if err != nil {
+		return plan, err
+	}
+
+	for _, remote := range o.remotes {
+		fetching := slices.Contains(fetchRemotes, remote)
+		pushing := slices.Contains(pushRemotes, remote)
+
+		if !fetching && !pushing {
+			continue
+		}
+
+		var tags []string
+		tags, err = o.remoteTags(remote)
+
+		if err != nil {
+			return plan, err
+		}
+
+		tagSync := TagSync{Remote: remote, Fetch: []string{}, Push: []string{}}
+
+		if fetching {
+			tagSync.Fetch = missing(tags, localTags)
+		}
+
+		if pushing {
+			tagSync.Push = missing(localTags, tags)
+		}
+
+		plan.Tags = append(plan.Tags, tagSync)
+	}
+
+	return plan, nil
+}
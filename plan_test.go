@@ -0,0 +1,197 @@
+package kick
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// writeWorkingFiles creates files in a fake repository, relative to its directory.
+func writeWorkingFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for pth, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, pth), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// descriptions lists the descriptions of plan operations.
+func descriptions(operations []Operation) []string {
+	var lines []string
+
+	for _, operation := range operations {
+		lines = append(lines, operation.Description)
+	}
+
+	return lines
+}
+
+// mutatingPrefixes lists git arguments that change a repository.
+var mutatingPrefixes = []string{"add", "commit", "pull", "push", "fetch", "reset", "rm"}
+
+func TestPlan(t *testing.T) {
+	config, runner := fakeConfig(
+		t,
+		fakeResponse{prefix: "status --porcelain=v1", stdout: " M README.md\x00?? new.txt\x00"},
+		fakeResponse{prefix: "symbolic-ref --quiet --short HEAD", stdout: "main\n"},
+		fakeResponse{prefix: "tag --list", stdout: "v1\nv2\n"},
+		fakeResponse{prefix: "ls-remote --tags --refs origin", stdout: "a1\trefs/tags/v2\nb2\trefs/tags/v3\n"},
+	)
+	writeWorkingFiles(t, config.Dir, map[string]string{"README.md": "readme\n", "new.txt": "new\n"})
+	config.CommitMessage = "up"
+	plan, err := config.Plan()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := []string{"README.md", "new.txt"}; !slices.Equal(plan.Files, expected) {
+		t.Errorf("Files: got %q, expected %q", plan.Files, expected)
+	}
+
+	if !plan.Commit || plan.CommitMessage != "up" {
+		t.Errorf("expected a commit with message up, got %v %q", plan.Commit, plan.CommitMessage)
+	}
+
+	if expected := []TagSync{{Remote: "origin", Fetch: []string{"v3"}, Push: []string{"v1"}}}; !equalTagSyncs(plan.Tags, expected) {
+		t.Errorf("Tags: got %+v, expected %+v", plan.Tags, expected)
+	}
+
+	operations := descriptions(plan.Operations)
+
+	for _, expected := range []string{
+		"git add .",
+		"scan staged changes for secrets",
+		"git commit -m up",
+		"git pull --no-rebase --ff --all",
+		"git push --all",
+		"git fetch --tags --all",
+		"git push origin --tags",
+	} {
+		if !slices.Contains(operations, expected) {
+			t.Errorf("missing operation %q in %q", expected, operations)
+		}
+	}
+
+	for _, prefix := range mutatingPrefixes {
+		if runner.called(prefix + " ") {
+			t.Errorf("plan ran git %s, calls: %q", prefix, runner.calls)
+		}
+	}
+}
+
+// equalTagSyncs compares tag exchanges.
+func equalTagSyncs(xs []TagSync, ys []TagSync) bool {
+	return slices.EqualFunc(xs, ys, func(x TagSync, y TagSync) bool {
+		return x.Remote == y.Remote && slices.Equal(x.Fetch, y.Fetch) && slices.Equal(x.Push, y.Push)
+	})
+}
+
+func TestPlanNothingToCommit(t *testing.T) {
+	config, _ := fakeConfig(t)
+	config.SyncTags = false
+	plan, err := config.Plan()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if plan.Commit || len(plan.Files) != 0 {
+		t.Errorf("expected no commit, got %+v", plan)
+	}
+}
+
+func TestPlanJSONRendersEmptyLists(t *testing.T) {
+	config, _ := fakeConfig(t)
+	config.SyncTags = false
+	plan, err := config.Plan()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(plan)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fields map[string]json.RawMessage
+
+	if err = json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"files", "tags"} {
+		if string(fields[key]) != "[]" {
+			t.Errorf("%s: got %s, expected an empty array", key, fields[key])
+		}
+	}
+}
+
+func TestPlanJSONRendersEmptyTagLists(t *testing.T) {
+	config, _ := fakeConfig(t, fakeResponse{prefix: "ls-remote --tags --refs origin", stdout: ""})
+	plan, err := config.Plan()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(plan.Tags)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := `[{"remote":"origin","fetch":[],"push":[]}]`; string(data) != expected {
+		t.Errorf("got %s, expected %s", data, expected)
+	}
+}
+
+func TestPlanGuards(t *testing.T) {
+	config, _ := fakeConfig(t, fakeResponse{prefix: "status --porcelain=v1", stdout: "?? small.txt\x00?? large.txt\x00"})
+	writeWorkingFiles(t, config.Dir, map[string]string{"small.txt": "s", "large.txt": strings.Repeat("l", 64)})
+	config.SyncTags = false
+	config.MaxFileSize = 16
+
+	if _, err := config.Plan(); ExitCode(err) != ExitGuard {
+		t.Errorf("expected the size guard to refuse, got %v", err)
+	}
+
+	config.SizePolicy = SizePolicyUnstage
+	plan, err := config.Plan()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := []string{"small.txt"}; !slices.Equal(plan.Files, expected) {
+		t.Errorf("Files: got %q, expected %q", plan.Files, expected)
+	}
+}
+
+func TestPlanSkipsMessageCommand(t *testing.T) {
+	config, _ := fakeConfig(t, fakeResponse{prefix: "status --porcelain=v1", stdout: "?? a.txt\x00"})
+	writeWorkingFiles(t, config.Dir, map[string]string{"a.txt": "a"})
+	config.SyncTags = false
+	config.MessageMode = MessageModeCommand
+	config.MessageCommand = "echo ran > ran.txt"
+	plan, err := config.Plan()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if plan.CommitMessage != commandMessagePlaceholder {
+		t.Errorf("got message %q, expected %q", plan.CommitMessage, commandMessagePlaceholder)
+	}
+
+	if _, err = os.Stat(filepath.Join(config.Dir, "ran.txt")); err == nil {
+		t.Error("plan ran the message command")
+	}
+}
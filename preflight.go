@@ -0,0 +1,245 @@
+package kick
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// MinimumGitVersion denotes the oldest supported git release.
+const MinimumGitVersion = "2.46.1"
+
+// CheckGitVersion verifies that git meets MinimumGitVersion.
+const CheckGitVersion = "git-version"
+
+// CheckBare verifies that the repository has a working tree.
+const CheckBare = "bare"
+
+// CheckDetachedHead verifies that a branch is checked out.
+const CheckDetachedHead = "detached-head"
+
+// CheckInProgress verifies that no merge, rebase, cherry-pick, revert, or bisect is underway.
+const CheckInProgress = "in-progress"
+
+// CheckUpstream verifies that the current branch tracks an upstream branch.
+const CheckUpstream = "upstream"
+
+// CheckShallow verifies that the repository is not a shallow clone.
+const CheckShallow = "shallow"
+
+// PreflightChecks lists the supported preflight checks, in order.
+var PreflightChecks = []string{
+	CheckGitVersion,
+	CheckBare,
+	CheckDetachedHead,
+	CheckInProgress,
+	CheckUpstream,
+	CheckShallow,
+}
+
+// PreflightError reports a repository unfit for syncing.
+type PreflightError struct {
+	// Check names the failed check, such as CheckDetachedHead.
+	Check string
+
+	// Reason describes the failure.
+	Reason string
+}
+
+// Error renders the failed check.
+func (o PreflightError) Error() string {
+	return fmt.Sprintf("preflight check %s failed: %s", o.Check, o.Reason)
+}
+
+// validateSkipChecks rejects unsupported preflight check names.
+func validateSkipChecks(checks []string) error {
+	for _, check := range checks {
+		if !slices.Contains(PreflightChecks, check) {
+			return fmt.Errorf("unsupported preflight check %q, expected one of %v", check, PreflightChecks)
+		}
+	}
+
+	return nil
+}
+
+// versionPattern extracts dotted version numbers.
+var versionPattern = regexp.MustCompile(`\d+(\.\d+)+`)
+
+// parseVersion extracts numeric version components from git version output,
+// such as "git version 2.46.1.windows.1".
+func parseVersion(s string) ([]int, error) {
+	match := versionPattern.FindString(s)
+
+	if match == "" {
+		return nil, fmt.Errorf("unable to parse version from %q", s)
+	}
+
+	var components []int
+
+	for _, field := range strings.Split(match, ".") {
+		component, err := strconv.Atoi(field)
+
+		if err != nil {
+			return nil, err
+		}
+
+		components = append(components, component)
+	}
+
+	return components, nil
+}
+
+// checkGitVersion verifies that git meets MinimumGitVersion.
+func (o Config) checkGitVersion() error {
+	lines, err := o.gitLines(StepPreflight, "version")
+
+	if err != nil {
+		return err
+	}
+
+	if len(lines) == 0 {
+		return PreflightError{Check: CheckGitVersion, Reason: "unable to query git version"}
+	}
+
+	version, err := parseVersion(lines[0])
+
+	if err != nil {
+		return err
+	}
+
+	minimum, err := parseVersion(MinimumGitVersion)
+
+	if err != nil {
+		return err
+	}
+
+	if slices.Compare(version, minimum) < 0 {
+		return PreflightError{
+			Check:  CheckGitVersion,
+			Reason: fmt.Sprintf("%s is older than the minimum supported git %s", lines[0], MinimumGitVersion),
+		}
+	}
+
+	return nil
+}
+
+// queryFlag queries a boolean rev-parse flag, such as --is-bare-repository.
+func (o Config) queryFlag(flag string) (bool, error) {
+	lines, err := o.gitLines(StepPreflight, "rev-parse", flag)
+
+	if err != nil {
+		return false, err
+	}
+
+	return len(lines) != 0 && lines[0] == "true", nil
+}
+
+// checkBare verifies that the repository has a working tree.
+func (o Config) checkBare() error {
+	bare, err := o.queryFlag("--is-bare-repository")
+
+	if err != nil {
+		return err
+	}
+
+	if bare {
+		return PreflightError{Check: CheckBare, Reason: "bare repositories have no working tree to sync"}
+	}
+
+	return nil
+}
+
+// checkDetachedHead verifies that a branch is checked out.
+func (o Config) checkDetachedHead() error {
+	if o.currentBranch() == "" {
+		return PreflightError{Check: CheckDetachedHead, Reason: "HEAD is detached, check out a branch"}
+	}
+
+	return nil
+}
+
+// inProgressMarkers maps git directory entries to the operations they indicate.
+var inProgressMarkers = []struct {
+	name      string
+	operation string
+}{
+	{"MERGE_HEAD", "merge"},
+	{"rebase-merge", "rebase"},
+	{"rebase-apply", "rebase"},
+	{"CHERRY_PICK_HEAD", "cherry-pick"},
+	{"REVERT_HEAD", "revert"},
+	{"BISECT_LOG", "bisect"},
+}
+
+// checkInProgress verifies that no merge, rebase, cherry-pick, revert, or bisect is underway.
+func (o Config) checkInProgress() error {
+	for _, marker := range inProgressMarkers {
+		if o.gitPathExists(marker.name) {
+			return PreflightError{
+				Check:  CheckInProgress,
+				Reason: fmt.Sprintf("a %s is in progress, finish or abort it first", marker.operation),
+			}
+		}
+	}
+
+	return nil
+}
+
+// hasUpstream reports whether the current branch tracks an upstream branch.
+func (o Config) hasUpstream() bool {
+	_, err := o.gitLines(StepPreflight, "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}")
+	return err == nil
+}
+
+// checkUpstream verifies that the current branch tracks an upstream branch,
+// unless SetUpstream will link one.
+func (o Config) checkUpstream() error {
+	if !o.SetUpstream && !o.hasUpstream() {
+		return PreflightError{Check: CheckUpstream, Reason: "the current branch has no upstream branch"}
+	}
+
+	return nil
+}
+
+// checkShallow verifies that the repository is not a shallow clone.
+func (o Config) checkShallow() error {
+	shallow, err := o.queryFlag("--is-shallow-repository")
+
+	if err != nil {
+		return err
+	}
+
+	if shallow {
+		return PreflightError{Check: CheckShallow, Reason: "shallow clones may fail to merge, fetch full history with git fetch --unshallow"}
+	}
+
+	return nil
+}
+
+// Preflight verifies that the repository is safe to sync, before any mutation.
+//
+// Checks named in SkipChecks are skipped.
+func (o Config) Preflight() error {
+	checks := map[string]func() error{
+		CheckGitVersion:   o.checkGitVersion,
+		CheckBare:         o.checkBare,
+		CheckDetachedHead: o.checkDetachedHead,
+		CheckInProgress:   o.checkInProgress,
+		CheckUpstream:     o.checkUpstream,
+		CheckShallow:      o.checkShallow,
+	}
+
+	for _, check := range PreflightChecks {
+		if slices.Contains(o.SkipChecks, check) {
+			continue
+		}
+
+		if err := checks[check](); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
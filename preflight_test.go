@@ -0,0 +1,148 @@
+package kick
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestParseVersion(t *testing.T) {
+	for _, tc := range []struct {
+		s        string
+		expected []int
+	}{
+		{"git version 2.46.1", []int{2, 46, 1}},
+		{"git version 2.46.1.windows.1", []int{2, 46, 1}},
+		{"git version 2.39.5 (Apple Git-154)", []int{2, 39, 5}},
+	} {
+		t.Run(tc.s, func(t *testing.T) {
+			components, err := parseVersion(tc.s)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !slices.Equal(components, tc.expected) {
+				t.Errorf("got %v, expected %v", components, tc.expected)
+			}
+		})
+	}
+}
+
+func TestParseVersionInvalid(t *testing.T) {
+	if components, err := parseVersion("git version unknown"); err == nil {
+		t.Errorf("got %v, expected an error", components)
+	}
+}
+
+func TestValidateSkipChecks(t *testing.T) {
+	if err := validateSkipChecks(PreflightChecks); err != nil {
+		t.Error(err)
+	}
+
+	if err := validateSkipChecks([]string{"everything"}); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+// healthyResponses answer preflight queries for a repository fit to sync.
+var healthyResponses = []fakeResponse{
+	{prefix: "version", stdout: "git version " + MinimumGitVersion + "\n"},
+	{prefix: "rev-parse --is-bare-repository", stdout: "false\n"},
+	{prefix: "rev-parse --is-shallow-repository", stdout: "false\n"},
+	{prefix: "symbolic-ref --quiet --short HEAD", stdout: "main\n"},
+	{prefix: "rev-parse --abbrev-ref --symbolic-full-name @{upstream}", stdout: "origin/main\n"},
+}
+
+func TestPreflight(t *testing.T) {
+	exitErr := errors.New("exit status 128")
+
+	for _, tc := range []struct {
+		name        string
+		responses   []fakeResponse
+		markers     []string
+		setUpstream bool
+		skip        []string
+		expected    string
+	}{
+		{name: "healthy"},
+		{
+			name:      "old git",
+			responses: []fakeResponse{{prefix: "version", stdout: "git version 2.39.5\n"}},
+			expected:  CheckGitVersion,
+		},
+		{
+			name:      "newer git",
+			responses: []fakeResponse{{prefix: "version", stdout: "git version 3.0.0\n"}},
+		},
+		{
+			name:      "bare",
+			responses: []fakeResponse{{prefix: "rev-parse --is-bare-repository", stdout: "true\n"}},
+			expected:  CheckBare,
+		},
+		{
+			name:      "detached",
+			responses: []fakeResponse{{prefix: "symbolic-ref", err: exitErr}},
+			expected:  CheckDetachedHead,
+		},
+		{
+			name:     "cherry-pick in progress",
+			markers:  []string{"CHERRY_PICK_HEAD"},
+			expected: CheckInProgress,
+		},
+		{
+			name:      "no upstream",
+			responses: []fakeResponse{{prefix: "rev-parse --abbrev-ref --symbolic-full-name @{upstream}", err: exitErr}},
+			expected:  CheckUpstream,
+		},
+		{
+			name:        "no upstream with set upstream",
+			responses:   []fakeResponse{{prefix: "rev-parse --abbrev-ref --symbolic-full-name @{upstream}", err: exitErr}},
+			setUpstream: true,
+		},
+		{
+			name:      "shallow",
+			responses: []fakeResponse{{prefix: "rev-parse --is-shallow-repository", stdout: "true\n"}},
+			expected:  CheckShallow,
+		},
+		{
+			name:      "skipped",
+			responses: []fakeResponse{{prefix: "rev-parse --is-shallow-repository", stdout: "true\n"}},
+			skip:      []string{CheckShallow},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			config, runner := fakeConfig(t)
+			runner.responses = append(append(slices.Clone(tc.responses), gitPathResponses(t, config.Dir, tc.markers...)...), healthyResponses...)
+			config.SkipChecks = tc.skip
+			config.SetUpstream = tc.setUpstream
+			err := config.Preflight()
+			var preflightErr PreflightError
+
+			switch {
+			case tc.expected == "" && err != nil:
+				t.Errorf("unexpected failure: %v", err)
+			case tc.expected != "" && (!errors.As(err, &preflightErr) || preflightErr.Check != tc.expected):
+				t.Errorf("expected failed check %s, got %v", tc.expected, err)
+			case tc.expected != "" && ExitCode(err) != ExitPrecondition:
+				t.Errorf("got exit code %d, expected %d", ExitCode(err), ExitPrecondition)
+			}
+		})
+	}
+}
+
+func TestKickPreflightFailsBeforeMutating(t *testing.T) {
+	config, runner := fakeConfig(t)
+	runner.responses = append([]fakeResponse{{prefix: "rev-parse --is-shallow-repository", stdout: "true\n"}}, healthyResponses...)
+	config.SkipChecks = nil
+
+	if code := ExitCode(config.Kick()); code != ExitPrecondition {
+		t.Errorf("got exit code %d, expected %d", code, ExitPrecondition)
+	}
+
+	for _, prefix := range mutatingPrefixes {
+		if runner.called(prefix + " ") {
+			t.Errorf("unexpected git %s, calls: %q", prefix, runner.calls)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package kick
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// PullStrategyMerge reconciles divergent branches with a merge commit.
+const PullStrategyMerge = "merge"
+
+// PullStrategyRebase reconciles divergent branches by rebasing local commits.
+const PullStrategyRebase = "rebase"
+
+// PullStrategyFastForwardOnly refuses to reconcile divergent branches.
+const PullStrategyFastForwardOnly = "ff-only"
+
+// PullStrategies lists the supported pull strategies.
+var PullStrategies = []string{
+	PullStrategyMerge,
+	PullStrategyRebase,
+	PullStrategyFastForwardOnly,
+}
+
+// validatePullStrategy rejects unsupported pull strategies.
+func validatePullStrategy(strategy string) error {
+	if !slices.Contains(PullStrategies, strategy) {
+		return fmt.Errorf("unsupported pull strategy %q, expected one of %v", strategy, PullStrategies)
+	}
+
+	return nil
+}
+
+// pullStrategyArgs denotes git pull arguments selecting a pull strategy,
+// overriding any pull.rebase or pull.ff git configuration.
+func pullStrategyArgs(strategy string) []string {
+	switch strategy {
+	case PullStrategyRebase:
+		return []string{"--rebase"}
+	case PullStrategyFastForwardOnly:
+		return []string{"--ff-only"}
+	default:
+		return []string{"--no-rebase", "--ff"}
+	}
+}
+
+// ConflictPolicyAbort aborts conflicting pulls, restoring the pre-pull HEAD.
+const ConflictPolicyAbort = "abort"
+
+// ConflictPolicyLeave leaves conflicting pulls in place for manual resolution.
+const ConflictPolicyLeave = "leave"
+
+// ConflictPolicyCopy resolves conflicting pulls by writing conflict copies,
+// falling back to ConflictPolicyAbort.
+const ConflictPolicyCopy = "copy"
+
+// ConflictPolicies lists the supported conflict policies.
+var ConflictPolicies = []string{
+	ConflictPolicyAbort,
+	ConflictPolicyLeave,
+	ConflictPolicyCopy,
+}
+
+// validateConflictPolicy rejects unsupported conflict policies.
+func validateConflictPolicy(policy string) error {
+	if !slices.Contains(ConflictPolicies, policy) {
+		return fmt.Errorf("unsupported conflict policy %q, expected one of %v", policy, ConflictPolicies)
+	}
+
+	return nil
+}
+
+// validateConflictWinner rejects unsupported conflict winners.
+func validateConflictWinner(winner string) error {
+	if !slices.Contains(ConflictWinners, winner) {
+		return fmt.Errorf("unsupported conflict winner %q, expected one of %v", winner, ConflictWinners)
+	}
+
+	return nil
+}
+
+// gitPath resolves a path within the git directory, such as MERGE_HEAD.
+func (o Config) gitPath(name string) (string, error) {
+	lines, err := o.gitLines(StepQuery, "rev-parse", "--git-path", name)
+
+	if err != nil {
+		return "", err
+	}
+
+	if len(lines) == 0 {
+		return "", fmt.Errorf("unable to locate git path %s", name)
+	}
+
+	pth := lines[0]
+
+	if !filepath.IsAbs(pth) {
+		pth = filepath.Join(o.Dir, pth)
+	}
+
+	return pth, nil
+}
+
+// gitPathExists reports whether a path within the git directory exists.
+func (o Config) gitPathExists(name string) bool {
+	pth, err := o.gitPath(name)
+
+	if err != nil {
+		return false
+	}
+
+	_, err = os.Stat(pth)
+	return err == nil
+}
+
+// conflictedPaths queries paths with unresolved conflicts.
+func (o Config) conflictedPaths() ([]string, error) {
+	return o.gitPaths(StepPull, "diff", "--name-only", "-z", "--diff-filter=U")
+}
+
+// abortPull abandons any in-progress rebase or merge.
+func (o Config) abortPull() error {
+	var err error
+
+	switch {
+	case o.rebasing():
+		_, err = o.git(StepPull, "rebase", "--abort")
+	case o.gitPathExists("MERGE_HEAD"):
+		_, err = o.git(StepPull, "merge", "--abort")
+	}
+
+	return err
+}
+
+// resolveConflicts resolves pull conflicts automatically,
+// first with MergeRules, then with conflict copies under ConflictPolicyCopy,
+// continuing until the merge or rebase completes.
+//
+// Fails when any conflicting path remains unresolved.
+func (o Config) resolveConflicts() error {
+	host, err := os.Hostname()
+
+	if err != nil {
+		return err
+	}
+
+	t := time.Now()
+
+	for {
+		var paths []string
+		paths, err = o.conflictedPaths()
+
+		if err != nil {
+			return err
+		}
+
+		if paths, err = o.applyMergeRules(paths); err != nil {
+			return err
+		}
+
+		if len(paths) != 0 {
+			if o.ConflictPolicy != ConflictPolicyCopy {
+				return fmt.Errorf("unresolved conflicting paths: %s", strings.Join(paths, ", "))
+			}
+
+			for _, pth := range paths {
+				if len(o.ConflictCopyPaths) != 0 && !matchAnyPath(o.ConflictCopyPaths, pth) {
+					return fmt.Errorf("conflicting path %s falls outside conflict copy paths", pth)
+				}
+			}
+
+			if err = o.copyConflicts(paths, host, t); err != nil {
+				return err
+			}
+		}
+
+		err = o.continuePull()
+		var mergeConflictErr MergeConflictError
+
+		if err == nil || !errors.As(err, &mergeConflictErr) {
+			return err
+		}
+	}
+}
+
+// handlePullConflict applies MergeRules and ConflictPolicy to a conflicting pull.
+func (o Config) handlePullConflict(cause MergeConflictError, head string) error {
+	if o.ConflictPolicy == ConflictPolicyCopy || len(o.mergeRules()) != 0 {
+		err := o.resolveConflicts()
+
+		if err == nil {
+			return nil
+		}
+
+		if o.Debug {
+			log.Println(err)
+		}
+
+		var mergeConflictErr MergeConflictError
+
+		if errors.As(err, &mergeConflictErr) {
+			cause = mergeConflictErr
+		}
+	}
+
+	paths, err := o.conflictedPaths()
+
+	if err != nil {
+		return err
+	}
+
+	conflictErr := PullConflictError{MergeConflictError: cause, Paths: paths}
+
+	if o.ConflictPolicy == ConflictPolicyLeave {
+		return conflictErr
+	}
+
+	if err = o.abortPull(); err != nil {
+		return err
+	}
+
+	if head != "" && o.head() != head {
+		if _, err = o.git(StepPull, "reset", "--keep", head); err != nil {
+			return err
+		}
+	}
+
+	conflictErr.Restored = head
+	return conflictErr
+}
@@ -0,0 +1,199 @@
+package kick
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestPullArgs(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		strategy  string
+		autostash bool
+		pullAll   bool
+		expected  []string
+	}{
+		{"merge", PullStrategyMerge, false, true, []string{"pull", "--no-rebase", "--ff", "--all"}},
+		{"rebase", PullStrategyRebase, false, true, []string{"pull", "--rebase", "--all"}},
+		{"fast forward only", PullStrategyFastForwardOnly, false, false, []string{"pull", "--ff-only"}},
+		{"autostash", PullStrategyRebase, true, false, []string{"pull", "--rebase", "--autostash"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			config := NewConfig()
+			config.PullStrategy = tc.strategy
+			config.Autostash = tc.autostash
+			config.PullAll = tc.pullAll
+
+			if args := config.pullArgs(); !slices.Equal(args, tc.expected) {
+				t.Errorf("got %q, expected %q", args, tc.expected)
+			}
+		})
+	}
+}
+
+// gitPathResponses marks operations as in progress in a fake repository,
+// creating entries such as MERGE_HEAD in its git directory and answering rev-parse --git-path queries for them.
+func gitPathResponses(t *testing.T, dir string, names ...string) []fakeResponse {
+	t.Helper()
+	var responses []fakeResponse
+
+	for _, name := range names {
+		pth := filepath.Join(".git", name)
+
+		if err := os.MkdirAll(filepath.Join(dir, ".git"), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, pth), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		responses = append(responses, fakeResponse{prefix: "rev-parse --git-path " + name, stdout: pth + "\n"})
+	}
+
+	return responses
+}
+
+// conflictResponses answer the git commands of a conflicting pull,
+// moving HEAD to a merged commit.
+var conflictResponses = []fakeResponse{
+	{prefix: "diff --name-only -z --diff-filter=U", stdout: "a.txt\x00café.md\x00"},
+	{prefix: "rev-parse --verify --quiet HEAD", stdout: "merged\n"},
+}
+
+func TestHandlePullConflict(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		policy    string
+		markers   []string
+		head      string
+		restored  string
+		called    []string
+		notCalled []string
+	}{
+		{
+			name:     "abort merge",
+			policy:   ConflictPolicyAbort,
+			markers:  []string{"MERGE_HEAD"},
+			head:     "before",
+			restored: "before",
+			called:   []string{"merge --abort", "reset --keep before"},
+		},
+		{
+			name:      "abort rebase",
+			policy:    ConflictPolicyAbort,
+			markers:   []string{"rebase-merge"},
+			head:      "before",
+			restored:  "before",
+			called:    []string{"rebase --abort", "reset --keep before"},
+			notCalled: []string{"merge --abort"},
+		},
+		{
+			name:      "abort with HEAD in place",
+			policy:    ConflictPolicyAbort,
+			markers:   []string{"MERGE_HEAD"},
+			head:      "merged",
+			restored:  "merged",
+			called:    []string{"merge --abort"},
+			notCalled: []string{"reset"},
+		},
+		{
+			name:      "abort on unborn branch",
+			policy:    ConflictPolicyAbort,
+			markers:   []string{"MERGE_HEAD"},
+			called:    []string{"merge --abort"},
+			notCalled: []string{"reset"},
+		},
+		{
+			name:      "leave",
+			policy:    ConflictPolicyLeave,
+			markers:   []string{"MERGE_HEAD"},
+			head:      "before",
+			notCalled: []string{"merge --abort", "reset"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			config, runner := fakeConfig(t)
+			runner.responses = append(runner.responses, gitPathResponses(t, config.Dir, tc.markers...)...)
+			runner.responses = append(runner.responses, conflictResponses...)
+			config.ConflictPolicy = tc.policy
+			cause := MergeConflictError{GitError{Step: StepPull, Args: []string{"pull"}, Err: errors.New("exit status 1")}}
+			err := config.handlePullConflict(cause, tc.head)
+			var pullConflictErr PullConflictError
+
+			if !errors.As(err, &pullConflictErr) {
+				t.Fatalf("expected PullConflictError, got %v", err)
+			}
+
+			if expected := []string{"a.txt", "café.md"}; !slices.Equal(pullConflictErr.Paths, expected) {
+				t.Errorf("Paths: got %q, expected %q", pullConflictErr.Paths, expected)
+			}
+
+			if pullConflictErr.Restored != tc.restored {
+				t.Errorf("Restored: got %q, expected %q", pullConflictErr.Restored, tc.restored)
+			}
+
+			if code := ExitCode(err); code != ExitPullConflict {
+				t.Errorf("got exit code %d, expected %d", code, ExitPullConflict)
+			}
+
+			for _, prefix := range tc.called {
+				if !runner.called(prefix) {
+					t.Errorf("expected git %s, calls: %q", prefix, runner.calls)
+				}
+			}
+
+			for _, prefix := range tc.notCalled {
+				if runner.called(prefix) {
+					t.Errorf("unexpected git %s, calls: %q", prefix, runner.calls)
+				}
+			}
+		})
+	}
+}
+
+func TestPullConflictAborts(t *testing.T) {
+	config, runner := fakeConfig(t)
+	runner.responses = append(runner.responses, fakeResponse{
+		prefix: "pull",
+		stdout: "CONFLICT (content): Merge conflict in a.txt\nAutomatic merge failed; fix conflicts and then commit the result.\n",
+		err:    errors.New("exit status 1"),
+	})
+	runner.responses = append(runner.responses, gitPathResponses(t, config.Dir, "MERGE_HEAD")...)
+	runner.responses = append(runner.responses, conflictResponses...)
+	err := config.Kick()
+
+	if code := ExitCode(err); code != ExitPullConflict {
+		t.Errorf("got exit code %d, expected %d: %v", code, ExitPullConflict, err)
+	}
+
+	if !runner.called("merge --abort") {
+		t.Errorf("expected git merge --abort, calls: %q", runner.calls)
+	}
+
+	if runner.called("push") {
+		t.Errorf("unexpected push, calls: %q", runner.calls)
+	}
+}
+
+func TestPullRefusedByLocalChanges(t *testing.T) {
+	config, runner := fakeConfig(t, fakeResponse{
+		prefix: "pull",
+		stderr: "error: Your local changes to the following files would be overwritten by merge:\n\ta.txt\nPlease commit your changes or stash them before you merge.\nAborting\n",
+		err:    errors.New("exit status 1"),
+	})
+	err := config.Kick()
+
+	if code := ExitCode(err); code != ExitFailure {
+		t.Errorf("got exit code %d, expected %d: %v", code, ExitFailure, err)
+	}
+
+	for _, prefix := range []string{"merge --abort", "rebase --abort", "reset", "push"} {
+		if runner.called(prefix) {
+			t.Errorf("unexpected git %s, calls: %q", prefix, runner.calls)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package kick
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// gitLines executes a git query for the given step, collecting nonblank output lines.
+func (o Config) gitLines(step string, args ...string) ([]string, error) {
+	result, err := o.git(step, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(result.Stdout))
+
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines, nil
+}
+
+// gitPaths executes a git command listing NUL separated paths, such as with -z,
+// preserving unusual characters and surrounding spaces verbatim.
+func (o Config) gitPaths(step string, args ...string) ([]string, error) {
+	result, err := o.git(step, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+
+	for _, pth := range strings.Split(string(result.Stdout), "\x00") {
+		if pth != "" {
+			paths = append(paths, pth)
+		}
+	}
+
+	return paths, nil
+}
+
+// gitConfigValue queries a git configuration key, yielding a blank string when unset.
+func (o Config) gitConfigValue(key string) string {
+	lines, err := o.gitLines(StepQuery, "config", "--get", key)
+
+	if err != nil || len(lines) == 0 {
+		return ""
+	}
+
+	return lines[0]
+}
+
+// currentBranch queries the checked out branch name, yielding a blank string when detached.
+func (o Config) currentBranch() string {
+	lines, err := o.gitLines(StepQuery, "symbolic-ref", "--quiet", "--short", "HEAD")
+
+	if err != nil || len(lines) == 0 {
+		return ""
+	}
+
+	return lines[0]
+}
+
+// head queries the checked out commit, yielding a blank string for unborn branches.
+func (o Config) head() string {
+	lines, err := o.gitLines(StepQuery, "rev-parse", "--verify", "--quiet", "HEAD")
+
+	if err != nil || len(lines) == 0 {
+		return ""
+	}
+
+	return lines[0]
+}
+
+// defaultRemote selects the remote git falls back on when a branch has no configuration.
+func (o Config) defaultRemote() string {
+	if slices.Contains(o.remotes, "origin") || len(o.remotes) != 1 {
+		return "origin"
+	}
+
+	return o.remotes[0]
+}
+
+// upstreamRemote queries the remote tracked by the current branch.
+func (o Config) upstreamRemote() string {
+	if branch := o.currentBranch(); branch != "" {
+		if remote := o.gitConfigValue(fmt.Sprintf("branch.%s.remote", branch)); remote != "" {
+			return remote
+		}
+	}
+
+	return o.defaultRemote()
+}
+
+// pushRemote queries the remote git pushes the current branch to.
+func (o Config) pushRemote() string {
+	if branch := o.currentBranch(); branch != "" {
+		if remote := o.gitConfigValue(fmt.Sprintf("branch.%s.pushRemote", branch)); remote != "" {
+			return remote
+		}
+	}
+
+	if remote := o.gitConfigValue("remote.pushDefault"); remote != "" {
+		return remote
+	}
+
+	return o.upstreamRemote()
+}
+
+// statusEntry describes a changed path, per git status --porcelain=v1.
+type statusEntry struct {
+	// Index denotes the staged status code, such as 'M'.
+	Index byte
+
+	// Worktree denotes the unstaged status code, such as 'M'.
+	Worktree byte
+
+	// Path denotes the repository relative path.
+	Path string
+
+	// OrigPath denotes the original path of renames and copies.
+	OrigPath string
+}
+
+// status queries paths with uncommitted changes, including untracked files.
+func (o Config) status() ([]statusEntry, error) {
+	result, err := o.git(StepQuery, "status", "--porcelain=v1", "-z", "--untracked-files=all")
+
+	if err != nil {
+		return nil, err
+	}
+
+	var statusEntries []statusEntry
+	entries := strings.Split(string(result.Stdout), "\x00")
+
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+
+		if len(entry) < 4 {
+			continue
+		}
+
+		e := statusEntry{Index: entry[0], Worktree: entry[1], Path: entry[3:]}
+
+		// Renames and copies follow with the original path.
+		if (entry[0] == 'R' || entry[0] == 'C') && i+1 < len(entries) {
+			i++
+			e.OrigPath = entries[i]
+		}
+
+		statusEntries = append(statusEntries, e)
+	}
+
+	return statusEntries, nil
+}
+
+// stagedChanges queries the changes staged for the next commit, detecting renames.
+//
+// Entries carry the staged status code in Index.
+func (o Config) stagedChanges() ([]statusEntry, error) {
+	result, err := o.git(StepQuery, "diff", "--cached", "--name-status", "-z", "-M")
+
+	if err != nil {
+		return nil, err
+	}
+
+	var statusEntries []statusEntry
+	fields := strings.Split(string(result.Stdout), "\x00")
+
+	// Records consist of a status, such as "M" or "R100", followed by one path,
+	// or for renames and copies, the original path and then the new path.
+	for i := 0; i+1 < len(fields); i += 2 {
+		code := fields[i]
+
+		if code == "" {
+			break
+		}
+
+		e := statusEntry{Index: code[0], Worktree: ' ', Path: fields[i+1]}
+
+		if (code[0] == 'R' || code[0] == 'C') && i+2 < len(fields) {
+			i++
+			e.OrigPath = e.Path
+			e.Path = fields[i+1]
+		}
+
+		statusEntries = append(statusEntries, e)
+	}
+
+	return statusEntries, nil
+}
+
+// changedPaths queries paths with uncommitted changes, including untracked files.
+func (o Config) changedPaths() ([]string, error) {
+	entries, err := o.status()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+
+	for _, entry := range entries {
+		paths = append(paths, entry.Path)
+	}
+
+	return paths, nil
+}
+
+// remoteTags queries the tag names published by a remote.
+func (o Config) remoteTags(remote string) ([]string, error) {
+	lines, err := o.gitLines(StepQuery, "ls-remote", "--tags", "--refs", remote)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []string
+
+	for _, line := range lines {
+		fields := strings.Fields(line)
+
+		if len(fields) != 2 {
+			continue
+		}
+
+		tags = append(tags, strings.TrimPrefix(fields[1], "refs/tags/"))
+	}
+
+	return tags, nil
+}
@@ -0,0 +1,42 @@
+package kick
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStagedChanges(t *testing.T) {
+	config, _ := fakeConfig(t, fakeResponse{
+		prefix: "diff --cached --name-status",
+		stdout: "R100\x00old name.md\x00new name.md\x00M\x00café.md\x00D\x00 gone \x00C75\x00a.txt\x00b.txt\x00",
+	})
+	changes, err := config.stagedChanges()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []statusEntry{
+		{Index: 'R', Worktree: ' ', Path: "new name.md", OrigPath: "old name.md"},
+		{Index: 'M', Worktree: ' ', Path: "café.md"},
+		{Index: 'D', Worktree: ' ', Path: " gone "},
+		{Index: 'C', Worktree: ' ', Path: "b.txt", OrigPath: "a.txt"},
+	}
+
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("got %+v, expected %+v", changes, expected)
+	}
+}
+
+func TestStagedChangesEmpty(t *testing.T) {
+	config, _ := fakeConfig(t)
+	changes, err := config.stagedChanges()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(changes) != 0 {
+		t.Errorf("got %+v, expected no changes", changes)
+	}
+}
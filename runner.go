@@ -0,0 +1,116 @@
+package kick
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+// GitCommand describes a git invocation.
+type GitCommand struct {
+	// Dir denotes the working directory (default: the current working directory).
+	Dir string
+
+	// Args denotes the arguments following the git executable.
+	Args []string
+
+	// Stdin denotes standard input (default: kick's standard input).
+	Stdin []byte
+}
+
+// GitResult describes the output of a git invocation.
+type GitResult struct {
+	// Stdout collects standard output.
+	Stdout []byte
+
+	// Stderr collects standard error.
+	Stderr []byte
+}
+
+// GitRunner executes git commands on behalf of Config.
+//
+// Custom implementations may capture, audit, or fake git operations.
+type GitRunner interface {
+	// Run executes a git command.
+	Run(command GitCommand) (GitResult, error)
+}
+
+// ExecGitRunner executes git commands as subprocesses.
+type ExecGitRunner struct {
+	// Debug enables additional logging (default: false).
+	Debug bool
+
+	// Isolate shields git from interrupt signals sent to kick,
+	// so that shutdown never interrupts a git step halfway through (default: false).
+	//
+	// Isolated git cannot prompt on the terminal, so it reads no standard input and runs with prompts disabled,
+	// failing rather than waiting on credentials, commit messages, or SSH passphrases.
+	Isolate bool
+}
+
+// nonInteractiveEnvironment disables git prompts for credentials and commit messages.
+var nonInteractiveEnvironment = []string{
+	"GIT_TERMINAL_PROMPT=0",
+	"GIT_EDITOR=true",
+}
+
+// batchSSHCommand denotes an SSH command failing rather than prompting for passphrases or host keys.
+const batchSSHCommand = "GIT_SSH_COMMAND=ssh -o BatchMode=yes"
+
+// isolatedEnviron extends an environment for isolated subprocesses,
+// which fail rather than wait on terminal input that never arrives.
+//
+// Preserves any custom GIT_SSH_COMMAND or GIT_SSH.
+func isolatedEnviron(env []string) []string {
+	env = append(env, nonInteractiveEnvironment...)
+	customSSH := slices.ContainsFunc(env, func(v string) bool {
+		return strings.HasPrefix(v, "GIT_SSH_COMMAND=") || strings.HasPrefix(v, "GIT_SSH=")
+	})
+
+	if !customSSH {
+		env = append(env, batchSSHCommand)
+	}
+
+	return env
+}
+
+// Run executes a git command as a subprocess.
+func (o ExecGitRunner) Run(command GitCommand) (GitResult, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.Command("git")
+	cmd.Args = append(cmd.Args, command.Args...)
+	cmd.Dir = command.Dir
+
+	cmd.Env = os.Environ()
+	cmd.Stdin = os.Stdin
+
+	if o.Isolate {
+		cmd.SysProcAttr = isolatedProcAttr()
+		cmd.Env = isolatedEnviron(cmd.Env)
+		cmd.Stdin = nil
+	}
+
+	if command.Stdin != nil {
+		cmd.Stdin = bytes.NewReader(command.Stdin)
+	}
+
+	if o.Debug {
+		cmd.Stdout = io.MultiWriter(&stdout, os.Stdout)
+		cmd.Stderr = io.MultiWriter(&stderr, os.Stderr)
+	} else {
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+	}
+
+	if o.Debug {
+		log.Printf("cmd: %v\n", cmd)
+	}
+
+	err := cmd.Run()
+	return GitResult{Stdout: stdout.Bytes(), Stderr: stderr.Bytes()}, err
+}
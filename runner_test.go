@@ -0,0 +1,53 @@
+package kick
+
+import (
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestIsolatedEnviron(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		env      []string
+		batchSSH bool
+	}{
+		{"default", []string{"HOME=/home/user"}, true},
+		{"custom ssh command", []string{"GIT_SSH_COMMAND=ssh -i key"}, false},
+		{"custom ssh program", []string{"GIT_SSH=plink"}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			env := isolatedEnviron(slices.Clone(tc.env))
+
+			for _, v := range append(nonInteractiveEnvironment, tc.env...) {
+				if !slices.Contains(env, v) {
+					t.Errorf("missing %s in %q", v, env)
+				}
+			}
+
+			if batchSSH := slices.Contains(env, batchSSHCommand); batchSSH != tc.batchSSH {
+				t.Errorf("got batch ssh %v, expected %v in %q", batchSSH, tc.batchSSH, env)
+			}
+		})
+	}
+}
+
+func TestShellIsolated(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell fixtures use sh")
+	}
+
+	config, _ := fakeConfig(t)
+	config.isolate = true
+	t.Setenv("GIT_TERMINAL_PROMPT", "1")
+	output, err := config.shell(`printf '%s %s' "$GIT_TERMINAL_PROMPT" "$GIT_EDITOR"`).Output()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s := strings.TrimSpace(string(output)); s != "0 true" {
+		t.Errorf("got %q, expected prompts disabled", s)
+	}
+}
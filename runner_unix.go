@@ -0,0 +1,19 @@
+//go:build !windows
+
+package kick
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// isolatedProcAttr places subprocesses in their own process group,
+// shielding them from terminal signals addressed to kick.
+func isolatedProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setpgid: true}
+}
+
+// shellCommand prepares a command line for the platform shell.
+func shellCommand(command string) *exec.Cmd {
+	return exec.Command("sh", "-c", command)
+}
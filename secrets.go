@@ -0,0 +1,293 @@
+package kick
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// SecretAllowlistFilename denotes the optional file listing secret finding fingerprints to ignore,
+// relative to the repository directory.
+const SecretAllowlistFilename = ".kicksecrets"
+
+// SecretEntropyThreshold denotes the minimum Shannon entropy, in bits per character,
+// of tokens flagged in known secret files.
+const SecretEntropyThreshold = 4.0
+
+// secretRule identifies one kind of secret.
+type secretRule struct {
+	// name labels findings, such as "aws-access-key-id".
+	name string
+
+	// pattern matches the secret.
+	pattern *regexp.Regexp
+}
+
+// secretRules list high-confidence secret patterns, checked in every file.
+var secretRules = []secretRule{
+	{"private-key", regexp.MustCompile(`-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY(?: BLOCK)?-----`)},
+	{"aws-access-key-id", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"gcp-api-key", regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}\b`)},
+	{"azure-storage-key", regexp.MustCompile(`AccountKey=[A-Za-z0-9+/]{86}==`)},
+	{"github-token", regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`)},
+	{"slack-token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`)},
+}
+
+// HighEntropyRule labels high-entropy tokens found in known secret files.
+const HighEntropyRule = "high-entropy-token"
+
+// secretFilePatterns match files that conventionally hold credentials, per MatchPath.
+var secretFilePatterns = []string{
+	".env",
+	".env.*",
+	"*.env",
+	"credentials",
+	"credentials.*",
+	"secrets.*",
+	"*.tfvars",
+	".npmrc",
+	".pypirc",
+	".netrc",
+	".git-credentials",
+}
+
+// tokenPattern extracts candidate tokens for entropy checks.
+var tokenPattern = regexp.MustCompile(`[A-Za-z0-9+/=_\-]{20,}`)
+
+// shannonEntropy computes the Shannon entropy of a string, in bits per character.
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+
+	for _, r := range s {
+		counts[r]++
+	}
+
+	var entropy float64
+	n := float64(len([]rune(s)))
+
+	for _, count := range counts {
+		p := float64(count) / n
+		entropy -= p * math.Log2(p)
+	}
+
+	return entropy
+}
+
+// SecretFinding describes a suspected secret in staged content.
+type SecretFinding struct {
+	// Path denotes the repository relative path.
+	Path string
+
+	// Line denotes the 1-based line number in the staged file.
+	Line int
+
+	// Rule labels the kind of secret, such as "private-key".
+	Rule string
+
+	// Fingerprint identifies the finding, for SecretAllowlistFilename.
+	Fingerprint string
+}
+
+// String renders a finding as path:line: rule (fingerprint).
+func (o SecretFinding) String() string {
+	return fmt.Sprintf("%s:%d: %s (%s)", o.Path, o.Line, o.Rule, o.Fingerprint)
+}
+
+// secretFingerprint identifies a finding by rule, path, and secret,
+// staying stable as surrounding lines move.
+func secretFingerprint(rule string, pth string, secret string) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{rule, pth, secret}, "\x00")))
+	return fmt.Sprintf("%x", sum[:8])
+}
+
+// SecretsError reports suspected secrets in staged content.
+type SecretsError struct {
+	// Findings lists the suspected secrets.
+	Findings []SecretFinding
+}
+
+// Error renders the findings.
+func (o SecretsError) Error() string {
+	lines := []string{fmt.Sprintf("refusing to commit %d suspected secret(s):", len(o.Findings))}
+
+	for _, finding := range o.Findings {
+		lines = append(lines, fmt.Sprintf("  %s", finding))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// scanLine checks a line of a file for secrets.
+func scanLine(pth string, lineNumber int, line string) []SecretFinding {
+	var findings []SecretFinding
+
+	for _, rule := range secretRules {
+		for _, match := range rule.pattern.FindAllString(line, -1) {
+			findings = append(findings, SecretFinding{
+				Path:        pth,
+				Line:        lineNumber,
+				Rule:        rule.name,
+				Fingerprint: secretFingerprint(rule.name, pth, match),
+			})
+		}
+	}
+
+	if !matchAnyPath(secretFilePatterns, path.Base(pth)) {
+		return findings
+	}
+
+	for _, token := range tokenPattern.FindAllString(line, -1) {
+		if shannonEntropy(token) >= SecretEntropyThreshold {
+			findings = append(findings, SecretFinding{
+				Path:        pth,
+				Line:        lineNumber,
+				Rule:        HighEntropyRule,
+				Fingerprint: secretFingerprint(HighEntropyRule, pth, token),
+			})
+		}
+	}
+
+	return findings
+}
+
+// hunkPattern extracts the new file line number from unified diff hunk headers.
+var hunkPattern = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
+// scanDiff checks the added lines of a zero context unified diff for secrets,
+// reading lines of any length.
+func scanDiff(diff []byte) ([]SecretFinding, error) {
+	var findings []SecretFinding
+	var pth string
+	header := false
+	lineNumber := 0
+	reader := bufio.NewReader(bytes.NewReader(diff))
+
+	for {
+		line, readErr := reader.ReadString('\n')
+
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			return nil, readErr
+		}
+
+		line = strings.TrimSuffix(line, "\n")
+
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			header = true
+		case header && strings.HasPrefix(line, "+++ "):
+			pth = strings.TrimPrefix(strings.TrimPrefix(line, "+++ "), "b/")
+		case strings.HasPrefix(line, "@@ "):
+			header = false
+			m := hunkPattern.FindStringSubmatch(line)
+
+			if m == nil {
+				return nil, fmt.Errorf("unable to parse diff hunk header %q", line)
+			}
+
+			n, err := strconv.Atoi(m[1])
+
+			if err != nil {
+				return nil, fmt.Errorf("unable to parse diff hunk header %q: %v", line, err)
+			}
+
+			lineNumber = n
+		case !header && strings.HasPrefix(line, "+"):
+			findings = append(findings, scanLine(pth, lineNumber, line[1:])...)
+			lineNumber++
+		}
+
+		if readErr != nil {
+			return findings, nil
+		}
+	}
+}
+
+// secretAllowlist reads finding fingerprints from SecretAllowlistFilename,
+// skipping blank lines and # comments.
+//
+// Yields no fingerprints when the file is missing.
+func (o Config) secretAllowlist() ([]string, error) {
+	content, err := os.ReadFile(filepath.Join(o.Dir, SecretAllowlistFilename))
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var fingerprints []string
+
+	for _, line := range strings.Split(string(content), "\n") {
+		// Allow trailing notes after the fingerprint.
+		fields := strings.Fields(line)
+
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		fingerprints = append(fingerprints, fields[0])
+	}
+
+	return fingerprints, nil
+}
+
+// CheckSecrets scans lines added by staged changes for suspected secrets,
+// ignoring findings whose fingerprints appear in SecretAllowlistFilename.
+func (o Config) CheckSecrets() error {
+	result, err := o.git(
+		StepSecrets,
+		"-c", "core.quotePath=false",
+		"diff", "--cached", "-U0", "--no-color", "--no-ext-diff", "--diff-filter=ACMR",
+	)
+
+	if err != nil {
+		return err
+	}
+
+	allowlist, err := o.secretAllowlist()
+
+	if err != nil {
+		return err
+	}
+
+	scanned, err := scanDiff(result.Stdout)
+
+	if err != nil {
+		return fmt.Errorf("secrets: %v", err)
+	}
+
+	var findings []SecretFinding
+
+	for _, finding := range scanned {
+		if slices.Contains(allowlist, finding.Fingerprint) {
+			if o.Debug {
+				log.Printf("secrets: allowing %s\n", finding)
+			}
+
+			continue
+		}
+
+		findings = append(findings, finding)
+	}
+
+	if len(findings) != 0 {
+		return SecretsError{Findings: findings}
+	}
+
+	return nil
+}
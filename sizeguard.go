@@ -0,0 +1,406 @@
+package kick
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ByteSize denotes a number of bytes,
+// written as a plain count or with a binary unit, such as "100MB" or "1.5GiB".
+type ByteSize int64
+
+// byteUnit scales sizes.
+type byteUnit struct {
+	// prefix denotes the unit prefix, such as "M".
+	prefix string
+
+	// size denotes the number of bytes per unit.
+	size ByteSize
+}
+
+// byteUnits lists binary size units, from largest to smallest.
+var byteUnits = []byteUnit{
+	{"T", 1 << 40},
+	{"G", 1 << 30},
+	{"M", 1 << 20},
+	{"K", 1 << 10},
+}
+
+// ParseByteSize interprets sizes such as "512", "64K", "100MB", or "1.5GiB",
+// treating units as powers of 1024.
+func ParseByteSize(s string) (ByteSize, error) {
+	invalidErr := fmt.Errorf("invalid size %q, expected a byte count with an optional unit such as KB, MB, GB, or TB", s)
+	trimmed := strings.ToUpper(strings.TrimSpace(s))
+	number := strings.TrimSpace(strings.TrimRightFunc(trimmed, unicode.IsLetter))
+	prefix := strings.TrimSuffix(strings.TrimSuffix(strings.TrimSpace(strings.TrimLeftFunc(trimmed, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})), "B"), "I")
+	var multiplier ByteSize = 1
+
+	if prefix != "" {
+		i := slices.IndexFunc(byteUnits, func(unit byteUnit) bool { return unit.prefix == prefix })
+
+		if i == -1 {
+			return 0, invalidErr
+		}
+
+		multiplier = byteUnits[i].size
+	}
+
+	f, err := strconv.ParseFloat(number, 64)
+
+	if err != nil || f < 0 {
+		return 0, invalidErr
+	}
+
+	return ByteSize(f * float64(multiplier)), nil
+}
+
+// String renders a size with the largest fitting binary unit, such as "1.5GiB".
+func (o ByteSize) String() string {
+	for _, unit := range byteUnits {
+		if o >= unit.size {
+			scaled := strconv.FormatFloat(float64(o)/float64(unit.size), 'f', 1, 64)
+			return fmt.Sprintf("%s%siB", strings.TrimSuffix(scaled, ".0"), unit.prefix)
+		}
+	}
+
+	return fmt.Sprintf("%dB", int64(o))
+}
+
+// UnmarshalText parses sizes from configuration files, per ParseByteSize.
+func (o *ByteSize) UnmarshalText(text []byte) error {
+	size, err := ParseByteSize(string(text))
+
+	if err != nil {
+		return err
+	}
+
+	*o = size
+	return nil
+}
+
+// MarshalText renders sizes for configuration files.
+func (o ByteSize) MarshalText() ([]byte, error) {
+	return []byte(o.String()), nil
+}
+
+// DefaultMaxFileSize denotes the default size limit per staged file,
+// matching common git hosting limits.
+const DefaultMaxFileSize ByteSize = 100 << 20
+
+// SizePolicyAbort refuses to commit when staged files violate size limits.
+const SizePolicyAbort = "abort"
+
+// SizePolicyUnstage unstages files violating size limits, with a warning, and commits the rest.
+const SizePolicyUnstage = "unstage"
+
+// SizePolicies lists the supported size policies.
+var SizePolicies = []string{
+	SizePolicyAbort,
+	SizePolicyUnstage,
+}
+
+// validateSizePolicy rejects unsupported size policies.
+func validateSizePolicy(policy string) error {
+	if !slices.Contains(SizePolicies, policy) {
+		return fmt.Errorf("unsupported size policy %q, expected one of %v", policy, SizePolicies)
+	}
+
+	return nil
+}
+
+// SizeViolation describes a staged file breaking size rules.
+type SizeViolation struct {
+	// Path denotes the repository relative path.
+	Path string
+
+	// Size denotes the staged file size.
+	Size ByteSize
+
+	// Reason explains the violation, such as "exceeds 100MiB".
+	Reason string
+}
+
+// String renders a violation as path (size): reason.
+func (o SizeViolation) String() string {
+	return fmt.Sprintf("%s (%s): %s", o.Path, o.Size, o.Reason)
+}
+
+// SizeError reports staged files breaking size rules.
+type SizeError struct {
+	// Violations lists the offending files.
+	Violations []SizeViolation
+}
+
+// Error renders the violations.
+func (o SizeError) Error() string {
+	lines := []string{fmt.Sprintf("refusing to commit %d file(s) breaking size rules:", len(o.Violations))}
+
+	for _, violation := range o.Violations {
+		lines = append(lines, fmt.Sprintf("  %s", violation))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// stagedFile describes a staged file.
+type stagedFile struct {
+	// path denotes the repository relative path.
+	path string
+
+	// object denotes the staged blob ID.
+	object string
+
+	// size denotes the staged file size.
+	size ByteSize
+
+	// binary reports whether git considers the file binary.
+	binary bool
+}
+
+// stagedFiles queries the files added or modified by staged changes, with their sizes.
+func (o Config) stagedFiles() ([]stagedFile, error) {
+	result, err := o.git(StepSizes, "diff", "--cached", "--raw", "-z", "--no-abbrev", "--no-renames", "--diff-filter=ACMT")
+
+	if err != nil {
+		return nil, err
+	}
+
+	var files []stagedFile
+	fields := strings.Split(string(result.Stdout), "\x00")
+
+	// Records alternate between ":oldmode newmode oldobject newobject status" and a path.
+	for i := 0; i+1 < len(fields); i += 2 {
+		metadata := strings.Fields(fields[i])
+
+		if len(metadata) < 4 {
+			break
+		}
+
+		files = append(files, stagedFile{path: fields[i+1], object: metadata[3]})
+	}
+
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	var objects bytes.Buffer
+
+	for _, file := range files {
+		fmt.Fprintln(&objects, file.object)
+	}
+
+	result, err = o.gitInput(StepSizes, objects.Bytes(), "cat-file", "--batch-check=%(objectsize)")
+
+	if err != nil {
+		return nil, err
+	}
+
+	sizes := strings.Split(string(result.Stdout), "\n")
+
+	for i := range files {
+		if i >= len(sizes) {
+			break
+		}
+
+		size, parseErr := strconv.ParseInt(strings.TrimSpace(sizes[i]), 10, 64)
+
+		if parseErr != nil {
+			// Submodules and other missing objects carry no size.
+			continue
+		}
+
+		files[i].size = ByteSize(size)
+	}
+
+	if !o.BlockBinary {
+		return files, nil
+	}
+
+	result, err = o.git(StepSizes, "diff", "--cached", "--numstat", "-z", "--no-renames", "--diff-filter=ACMT")
+
+	if err != nil {
+		return nil, err
+	}
+
+	var binaries []string
+
+	for _, record := range strings.Split(string(result.Stdout), "\x00") {
+		if pth, ok := strings.CutPrefix(record, "-\t-\t"); ok {
+			binaries = append(binaries, pth)
+		}
+	}
+
+	for i := range files {
+		files[i].binary = slices.Contains(binaries, files[i].path)
+	}
+
+	return files, nil
+}
+
+// sizeViolations identifies staged files breaking MaxFileSize, MaxTotalSize, or BlockBinary,
+// selecting the largest files first when the total exceeds MaxTotalSize.
+func (o Config) sizeViolations(files []stagedFile) []SizeViolation {
+	var violations []SizeViolation
+	var remaining []stagedFile
+	var total ByteSize
+
+	for _, file := range files {
+		switch {
+		case o.MaxFileSize > 0 && file.size > o.MaxFileSize:
+			violations = append(violations, SizeViolation{
+				Path:   file.path,
+				Size:   file.size,
+				Reason: fmt.Sprintf("exceeds the %s file size limit", o.MaxFileSize),
+			})
+		case o.BlockBinary && file.binary:
+			violations = append(violations, SizeViolation{Path: file.path, Size: file.size, Reason: "binary file"})
+		default:
+			remaining = append(remaining, file)
+			total += file.size
+		}
+	}
+
+	if o.MaxTotalSize <= 0 {
+		return violations
+	}
+
+	slices.SortStableFunc(remaining, func(a stagedFile, b stagedFile) int {
+		return cmp.Compare(b.size, a.size)
+	})
+
+	for _, file := range remaining {
+		if total <= o.MaxTotalSize {
+			break
+		}
+
+		violations = append(violations, SizeViolation{
+			Path:   file.path,
+			Size:   file.size,
+			Reason: fmt.Sprintf("staged changes exceed the %s total size limit", o.MaxTotalSize),
+		})
+		total -= file.size
+	}
+
+	return violations
+}
+
+// sizeLimited reports whether any size rule applies.
+func (o Config) sizeLimited() bool {
+	return o.MaxFileSize > 0 || o.MaxTotalSize > 0 || o.BlockBinary
+}
+
+// binarySniffLength denotes how many leading bytes sniffBinary inspects, matching git.
+const binarySniffLength = 8000
+
+// sniffBinary approximates git's binary detection, by looking for NUL bytes near the start of a file.
+func (o Config) sniffBinary(pth string) (bool, error) {
+	f, err := os.Open(pth)
+
+	if err != nil {
+		return false, err
+	}
+
+	defer func() {
+		if closeErr := f.Close(); closeErr != nil && o.Debug {
+			log.Println(closeErr)
+		}
+	}()
+
+	buf := make([]byte, binarySniffLength)
+	n, err := io.ReadFull(f, buf)
+
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return false, err
+	}
+
+	return bytes.IndexByte(buf[:n], 0) != -1, nil
+}
+
+// worktreeFiles describes the working tree files at the given repository relative paths,
+// for predicting size violations without staging.
+//
+// Skips missing paths, such as deletions, along with symlinks and directories.
+func (o Config) worktreeFiles(paths []string) ([]stagedFile, error) {
+	var files []stagedFile
+
+	for _, pth := range paths {
+		full := filepath.Join(o.Dir, filepath.FromSlash(pth))
+		info, err := os.Lstat(full)
+
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !info.Mode().IsRegular() {
+			continue
+		}
+
+		file := stagedFile{path: pth, size: ByteSize(info.Size())}
+
+		if o.BlockBinary {
+			if file.binary, err = o.sniffBinary(full); err != nil {
+				return nil, err
+			}
+		}
+
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+// CheckSizes applies SizePolicy to staged files breaking MaxFileSize, MaxTotalSize, or BlockBinary.
+func (o Config) CheckSizes() error {
+	if !o.sizeLimited() {
+		return nil
+	}
+
+	files, err := o.stagedFiles()
+
+	if err != nil {
+		return err
+	}
+
+	violations := o.sizeViolations(files)
+
+	if len(violations) == 0 {
+		return nil
+	}
+
+	if o.SizePolicy != SizePolicyUnstage {
+		return SizeError{Violations: violations}
+	}
+
+	var paths []string
+
+	for _, violation := range violations {
+		paths = append(paths, violation.Path)
+	}
+
+	if _, err = o.gitInput(StepSizes, pathspecInput(paths), unstageArgs()...); err != nil {
+		return err
+	}
+
+	for _, violation := range violations {
+		log.Printf("sizes: unstaged %s\n", violation)
+	}
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package kick
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestParseByteSize(t *testing.T) {
+	for _, tc := range []struct {
+		s        string
+		expected ByteSize
+	}{
+		{"0", 0},
+		{"512", 512},
+		{"64K", 64 << 10},
+		{"64kb", 64 << 10},
+		{"100MB", 100 << 20},
+		{" 100 MiB ", 100 << 20},
+		{"1.5GiB", 3 << 29},
+		{"2T", 2 << 40},
+	} {
+		t.Run(tc.s, func(t *testing.T) {
+			size, err := ParseByteSize(tc.s)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if size != tc.expected {
+				t.Errorf("got %d, expected %d", size, tc.expected)
+			}
+		})
+	}
+}
+
+func TestParseByteSizeInvalid(t *testing.T) {
+	for _, s := range []string{"", "MB", "-1", "10XB", "1.2.3K", "ten"} {
+		t.Run(s, func(t *testing.T) {
+			if size, err := ParseByteSize(s); err == nil {
+				t.Errorf("got %d, expected an error", size)
+			}
+		})
+	}
+}
+
+func TestByteSizeString(t *testing.T) {
+	for _, tc := range []struct {
+		size     ByteSize
+		expected string
+	}{
+		{0, "0B"},
+		{1023, "1023B"},
+		{1 << 10, "1KiB"},
+		{100 << 20, "100MiB"},
+		{3 << 29, "1.5GiB"},
+	} {
+		t.Run(tc.expected, func(t *testing.T) {
+			if s := tc.size.String(); s != tc.expected {
+				t.Errorf("got %q, expected %q", s, tc.expected)
+			}
+		})
+	}
+}
+
+func TestSizeViolations(t *testing.T) {
+	files := []stagedFile{
+		{path: "small.txt", size: 10},
+		{path: "medium.txt", size: 40},
+		{path: "large.bin", size: 50, binary: true},
+		{path: "huge.txt", size: 200},
+	}
+
+	for _, tc := range []struct {
+		name         string
+		maxFileSize  ByteSize
+		maxTotalSize ByteSize
+		blockBinary  bool
+		expected     []string
+	}{
+		{"unlimited", 0, 0, false, nil},
+		{"file size", 100, 0, false, []string{"huge.txt"}},
+		{"binary", 0, 0, true, []string{"large.bin"}},
+		{"every rule", 100, 30, true, []string{"large.bin", "huge.txt", "medium.txt"}},
+		{"total size drops largest first", 0, 100, false, []string{"huge.txt"}},
+		{"total size drops until within limit", 0, 55, false, []string{"huge.txt", "large.bin"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			config := NewConfig()
+			config.MaxFileSize = tc.maxFileSize
+			config.MaxTotalSize = tc.maxTotalSize
+			config.BlockBinary = tc.blockBinary
+			var paths []string
+
+			for _, violation := range config.sizeViolations(files) {
+				paths = append(paths, violation.Path)
+			}
+
+			if !slices.Equal(paths, tc.expected) {
+				t.Errorf("got %q, expected %q", paths, tc.expected)
+			}
+		})
+	}
+}
+
+// sizeResponses answer staged size queries for a large binary file and a small text file.
+var sizeResponses = []fakeResponse{
+	{prefix: "diff --cached --raw", stdout: ":000000 100644 0000 aaaa A\x00big.bin\x00:000000 100644 0000 bbbb A\x00small.txt\x00"},
+	{prefix: "cat-file --batch-check", stdout: "200\n10\n"},
+	{prefix: "diff --cached --numstat", stdout: "-\t-\tbig.bin\x001\t0\tsmall.txt\x00"},
+}
+
+func TestCheckSizes(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		maxFileSize ByteSize
+		blockBinary bool
+		policy      string
+		refused     bool
+		unstaged    bool
+	}{
+		{"within limits", 1000, false, SizePolicyAbort, false, false},
+		{"too large", 100, false, SizePolicyAbort, true, false},
+		{"binary", 1000, true, SizePolicyAbort, true, false},
+		{"unstage", 100, false, SizePolicyUnstage, false, true},
+		{"unlimited", 0, false, SizePolicyAbort, false, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			config, runner := fakeConfig(t, sizeResponses...)
+			config.MaxFileSize = tc.maxFileSize
+			config.BlockBinary = tc.blockBinary
+			config.SizePolicy = tc.policy
+			err := config.CheckSizes()
+			var sizeErr SizeError
+
+			if refused := errors.As(err, &sizeErr); refused != tc.refused {
+				t.Errorf("got %v, expected refusal %v", err, tc.refused)
+			}
+
+			if unstaged := runner.called("reset --quiet"); unstaged != tc.unstaged {
+				t.Errorf("got unstaging %v, expected %v, calls: %q", unstaged, tc.unstaged, runner.calls)
+			}
+		})
+	}
+}
@@ -2,15 +2,60 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
 
 	"github.com/mcandre/kick"
 )
 
+var flagDir = flag.String("C", "", "Run as if started in the given directory")
+var flagConfig = flag.String("config", "", "Load configuration from a TOML file")
 var flagDebug = flag.Bool("debug", false, "Enable additional logging")
+var flagMessage = flag.String("message", kick.DefaultCommitMessage, "Commit message text/template (blank prompts with core.editor)")
+var flagMessageMode = flag.String("message-mode", kick.MessageModeTemplate, fmt.Sprintf("Commit message mode, one of %v", kick.MessageModes))
+var flagSubjectLimit = flag.Int("subject-limit", kick.DefaultSubjectLimit, "Maximum length of generated commit subjects")
+var flagMessageFile = flag.String("message-file", "", "Commit message file, cleared after each commit by the file message mode")
+var flagMessageCommand = flag.String("message-command", "", "Shell command printing commit messages for the command message mode")
+var flagNonce = flag.Bool("nonce", false, "Update a nonce file to force a commit")
+var flagNonceMode = flag.String("nonce-mode", kick.NonceModeFile, fmt.Sprintf("Nonce mode, one of %v", kick.NonceModes))
+var flagInclude = flag.String("include", "", "Globs limiting staged paths, comma separated")
+var flagExclude = flag.String("exclude", "", "Globs excluded from staging, comma separated")
+var flagScanSecrets = flag.Bool("scan-secrets", true, "Refuse to commit staged changes containing suspected secrets")
+var flagMaxFileSize = flag.String("max-file-size", kick.DefaultMaxFileSize.String(), "Size limit per staged file, such as 100MB (0 disables)")
+var flagMaxTotalSize = flag.String("max-total-size", "0", "Total size limit of staged files, such as 1GB (0 disables)")
+var flagBlockBinary = flag.Bool("block-binary", false, "Reject staged binary files")
+var flagSizePolicy = flag.String("size-policy", kick.SizePolicyAbort, fmt.Sprintf("Handling of staged files breaking size rules, one of %v", kick.SizePolicies))
+var flagMaxDeletions = flag.Int("max-deletions", 0, "Maximum files deleted by a single commit (0 disables)")
+var flagMaxDeletionPercent = flag.Float64("max-deletion-percent", kick.DefaultMaxDeletionPercent, "Maximum percentage of tracked files deleted by a single commit (0 disables)")
+var flagMaxChanges = flag.Int("max-changes", 0, "Maximum paths touched by a single commit (0 disables)")
+var flagAllowMassChange = flag.Bool("allow-mass-change", false, "Override mass deletion and mass change limits for this run")
+var flagFetchAll = flag.Bool("fetch-all", true, "Fetch tags from all remotes")
+var flagPullAll = flag.Bool("pull-all", true, "Pull from all remotes")
+var flagPushAll = flag.Bool("push-all", true, "Push to all remotes")
+var flagSetUpstream = flag.Bool("set-upstream", false, "Push branches lacking an upstream branch with --set-upstream")
+var flagUpstreamRemote = flag.String("upstream-remote", "", "Remote that -set-upstream links branches to (default: the push remote)")
+var flagPullStrategy = flag.String("pull-strategy", kick.PullStrategyMerge, fmt.Sprintf("Pull strategy, one of %v", kick.PullStrategies))
+var flagAutostash = flag.Bool("autostash", false, "Stash local changes around pulls")
+var flagConflictPolicy = flag.String("conflict-policy", kick.ConflictPolicyAbort, fmt.Sprintf("Pull conflict policy, one of %v", kick.ConflictPolicies))
+var flagConflictWinner = flag.String("conflict-winner", kick.ConflictWinnerRemote, fmt.Sprintf("Side of a conflict kept in place by the copy conflict policy, one of %v", kick.ConflictWinners))
+var flagSkipChecks = flag.String("skip-checks", "", fmt.Sprintf("Preflight checks to skip, comma separated, any of %v", kick.PreflightChecks))
+var flagSyncTags = flag.Bool("sync-tags", true, "Push and pull tags")
+var flagWorkspace = flag.String("workspace", "", "Kick every git repository beneath a directory")
+var flagManifest = flag.String("manifest", "", "Kick the git repositories listed in a manifest file")
+var flagParallelism = flag.Int("parallelism", kick.DefaultParallelism, "Maximum repositories kicked concurrently in workspace mode")
+var flagWatch = flag.Bool("watch", false, "Kick continuously as the working tree changes")
+var flagDryRun = flag.Bool("dry-run", false, "Show planned git operations without running them")
+var flagJSON = flag.Bool("json", false, "Render -dry-run plans as JSON")
 var flagVersion = flag.Bool("version", false, "Show version banner")
 var flagHelp = flag.Bool("help", false, "Show usage menu")
 
@@ -26,6 +71,219 @@ func usage() {
 	flag.PrintDefaults()
 }
 
+// parseByteSize interprets a size flag, exiting on invalid usage.
+func parseByteSize(name string, value string) kick.ByteSize {
+	size, err := kick.ParseByteSize(value)
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid value %q for flag -%s: %v\n", value, name, err)
+		usage()
+		os.Exit(kick.ExitUsage)
+	}
+
+	return size
+}
+
+// advice suggests a remedy for a Kick failure.
+func advice(err error) string {
+	var authenticationErr kick.AuthenticationError
+	var pullConflictErr kick.PullConflictError
+	var mergeConflictErr kick.MergeConflictError
+	var nonFastForwardErr kick.NonFastForwardError
+	var noUpstreamErr kick.NoUpstreamError
+	var remoteUnreachableErr kick.RemoteUnreachableError
+	var repositoryNotFoundErr kick.RepositoryNotFoundError
+	var hookRejectionErr kick.HookRejectionError
+	var usageErr kick.UsageError
+	var preflightErr kick.PreflightError
+	var secretsErr kick.SecretsError
+	var sizeErr kick.SizeError
+	var massChangeErr kick.MassChangeError
+	var hookCommandErr kick.HookCommandError
+	var gitErr kick.GitError
+
+	switch {
+	case errors.As(err, &usageErr):
+		return "invalid settings, see -help and CONFIGURATION.md"
+	case errors.As(err, &preflightErr) && preflightErr.Check == kick.CheckBare:
+		return "bare repositories cannot be kicked, run kick within a clone instead"
+	case errors.As(err, &preflightErr) && preflightErr.Check == kick.CheckUpstream:
+		return "current branch has no upstream, enable -set-upstream or bypass the check with -skip-checks upstream"
+	case errors.As(err, &preflightErr):
+		return fmt.Sprintf("repository unfit for syncing, fix the problem or bypass the check with -skip-checks %s", preflightErr.Check)
+	case errors.As(err, &secretsErr):
+		return fmt.Sprintf("remove the secrets from the staged changes, or allow false positives by adding their fingerprints to %s", kick.SecretAllowlistFilename)
+	case errors.As(err, &sizeErr):
+		return "unstage or shrink the listed files, list them in .kickignore, or enable -size-policy unstage"
+	case errors.As(err, &massChangeErr):
+		return "review the staged changes with git status; if intended, rerun once with -allow-mass-change"
+	case errors.As(err, &hookCommandErr):
+		return fmt.Sprintf("%s hook failed, review the hook output above", hookCommandErr.Hook)
+	case errors.As(err, &authenticationErr):
+		return "authentication failed, check git credentials and SSH keys for the remote"
+	case errors.As(err, &pullConflictErr) && pullConflictErr.Restored != "":
+		return "pull conflicted and was aborted, repository unchanged; reconcile the listed paths manually with git pull"
+	case errors.As(err, &mergeConflictErr):
+		return "merge conflict, resolve the conflicting files and commit, or abort with git merge --abort / git rebase --abort"
+	case errors.As(err, &nonFastForwardErr):
+		return "push rejected as non-fast-forward, pull remote changes and try again"
+	case errors.As(err, &noUpstreamErr):
+		return "current branch has no upstream, enable -set-upstream or set one with git push --set-upstream <remote> <branch>"
+	case errors.As(err, &repositoryNotFoundErr):
+		return "remote repository not found, check the remote URL with git remote -v"
+	case errors.As(err, &remoteUnreachableErr):
+		return "remote unreachable, check network connectivity and the remote URL"
+	case errors.As(err, &hookRejectionErr):
+		return "change declined by a git hook, review the hook output above"
+	case errors.As(err, &gitErr):
+		return fmt.Sprintf("git failed during %s step, enable -debug for more detail", gitErr.Step)
+	default:
+		return "kick failed, enable -debug for more detail"
+	}
+}
+
+// kickWorkspace kicks multiple repositories, each with its own configuration, summarizing the results.
+func kickWorkspace() {
+	var repositories []string
+
+	if *flagWorkspace != "" {
+		discovered, err := kick.DiscoverRepositories(*flagWorkspace)
+
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		repositories = append(repositories, discovered...)
+	}
+
+	if *flagManifest != "" {
+		listed, err := kick.ReadManifest(*flagManifest)
+
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		repositories = append(repositories, listed...)
+	}
+
+	results := kick.KickWorkspace(repositories, *flagParallelism, loadConfig)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "REPOSITORY\tSTATUS\tDURATION")
+
+	for _, result := range results {
+		status := "ok"
+
+		if result.Err != nil {
+			summary, _, _ := strings.Cut(result.Err.Error(), "\n")
+			status = fmt.Sprintf("exit %d: %s", kick.ExitCode(result.Err), summary)
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\n", result.Dir, status, result.Duration.Round(time.Millisecond))
+	}
+
+	if err := w.Flush(); err != nil {
+		log.Fatal(err)
+	}
+
+	os.Exit(kick.WorkspaceExitCode(results))
+}
+
+// applyFlags overrides settings with any command line flags given explicitly.
+func applyFlags(config *kick.Config) {
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "debug":
+			config.Debug = *flagDebug
+		case "message":
+			config.CommitMessage = *flagMessage
+		case "message-mode":
+			config.MessageMode = *flagMessageMode
+		case "subject-limit":
+			config.SubjectLimit = *flagSubjectLimit
+		case "message-file":
+			config.MessageFile = *flagMessageFile
+		case "message-command":
+			config.MessageCommand = *flagMessageCommand
+		case "nonce":
+			config.Nonce = *flagNonce
+		case "nonce-mode":
+			config.NonceMode = *flagNonceMode
+		case "include":
+			config.Include = kick.ParseList(*flagInclude)
+		case "exclude":
+			config.Exclude = kick.ParseList(*flagExclude)
+		case "scan-secrets":
+			config.ScanSecrets = *flagScanSecrets
+		case "max-file-size":
+			config.MaxFileSize = parseByteSize(f.Name, *flagMaxFileSize)
+		case "max-total-size":
+			config.MaxTotalSize = parseByteSize(f.Name, *flagMaxTotalSize)
+		case "block-binary":
+			config.BlockBinary = *flagBlockBinary
+		case "size-policy":
+			config.SizePolicy = *flagSizePolicy
+		case "max-deletions":
+			config.MaxDeletions = *flagMaxDeletions
+		case "max-deletion-percent":
+			config.MaxDeletionPercent = *flagMaxDeletionPercent
+		case "max-changes":
+			config.MaxChanges = *flagMaxChanges
+		case "allow-mass-change":
+			config.AllowMassChange = *flagAllowMassChange
+		case "fetch-all":
+			config.FetchAll = *flagFetchAll
+		case "pull-all":
+			config.PullAll = *flagPullAll
+		case "push-all":
+			config.PushAll = *flagPushAll
+		case "sync-tags":
+			config.SyncTags = *flagSyncTags
+		case "set-upstream":
+			config.SetUpstream = *flagSetUpstream
+		case "upstream-remote":
+			config.UpstreamRemote = *flagUpstreamRemote
+		case "pull-strategy":
+			config.PullStrategy = *flagPullStrategy
+		case "autostash":
+			config.Autostash = *flagAutostash
+		case "conflict-policy":
+			config.ConflictPolicy = *flagConflictPolicy
+		case "conflict-winner":
+			config.ConflictWinner = *flagConflictWinner
+		case "skip-checks":
+			config.SkipChecks = kick.ParseList(*flagSkipChecks)
+		}
+	})
+}
+
+// loadConfig layers configuration files, environment variables, and flags over the defaults,
+// for the repository containing dir.
+func loadConfig(dir string) (kick.Config, error) {
+	config := kick.NewConfig()
+	config.Dir = dir
+
+	if err := config.ResolveDir(); err != nil {
+		return config, err
+	}
+
+	if err := config.LoadFiles(); err != nil {
+		return config, err
+	}
+
+	if *flagConfig != "" {
+		if err := config.LoadFile(*flagConfig); err != nil {
+			return config, err
+		}
+	}
+
+	if err := config.LoadEnvironment(); err != nil {
+		return config, err
+	}
+
+	applyFlags(&config)
+	return config, nil
+}
+
 func main() {
 	flag.Parse()
 
@@ -38,34 +296,68 @@ func main() {
 		os.Exit(0)
 	}
 
-	config := kick.NewConfig()
-	config.Debug = *flagDebug
-
-	if nonce, ok := os.LookupEnv(kick.NonceEnvironmentVariable); ok && nonce == "1" {
-		config.Nonce = true
+	for _, name := range kick.UnknownEnvironmentVariables() {
+		log.Printf("warning: ignoring unrecognized environment variable %s\n", name)
 	}
 
-	if pullAll, ok := os.LookupEnv(kick.PullAllEnvironmentVariable); ok && pullAll != "1" {
-		config.PullAll = false
-	}
+	if *flagWorkspace != "" || *flagManifest != "" {
+		if *flagDryRun || *flagWatch {
+			fmt.Fprintln(os.Stderr, "-dry-run and -watch are unavailable in workspace mode")
+			usage()
+			os.Exit(kick.ExitUsage)
+		}
 
-	if pushAll, ok := os.LookupEnv(kick.PushAllEnvironmentVariable); ok && pushAll != "1" {
-		config.PushAll = false
+		kickWorkspace()
 	}
 
-	if syncTags, ok := os.LookupEnv(kick.SyncTagsEnvironmentVariable); ok && syncTags != "1" {
-		config.SyncTags = false
+	config, err := loadConfig(*flagDir)
+
+	if err != nil {
+		log.Println(err)
+		log.Println(advice(err))
+		os.Exit(kick.ExitCode(err))
 	}
 
-	if commitMessage, ok := os.LookupEnv(kick.CommitMessageEnvironmentVariable); ok {
-		config.CommitMessage = commitMessage
+	if *flagDryRun {
+		var plan kick.Plan
+		plan, err = config.Plan()
+
+		if err != nil {
+			log.Println(err)
+			log.Println(advice(err))
+			os.Exit(kick.ExitCode(err))
+		}
+
+		if *flagJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+
+			if err = encoder.Encode(plan); err != nil {
+				log.Fatal(err)
+			}
+		} else {
+			fmt.Print(plan)
+		}
+
+		os.Exit(0)
 	}
 
-	if err := config.Kick(); err != nil {
-		if config.Debug {
-			log.Fatal(err)
+	if *flagWatch {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		if err = config.Watch(ctx); err != nil {
+			log.Println(err)
+			log.Println(advice(err))
+			os.Exit(kick.ExitCode(err))
 		}
 
-		log.Fatal("git crashed, enable -debug to diagnose")
+		return
+	}
+
+	if err = config.Kick(); err != nil {
+		log.Println(err)
+		log.Println(advice(err))
+		os.Exit(kick.ExitCode(err))
 	}
 }
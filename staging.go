@@ -0,0 +1,207 @@
+package kick
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// KickignoreFilename denotes the optional file listing glob patterns that kick never stages,
+// relative to the repository directory.
+const KickignoreFilename = ".kickignore"
+
+// pathspecArgs denotes git arguments reading NUL separated pathspecs from standard input.
+var pathspecArgs = []string{"--pathspec-from-file=-", "--pathspec-file-nul"}
+
+// pathspecInput encodes paths for pathspecArgs.
+func pathspecInput(paths []string) []byte {
+	var b bytes.Buffer
+
+	for _, pth := range paths {
+		b.WriteString(pth)
+		b.WriteByte(0)
+	}
+
+	return b.Bytes()
+}
+
+// kickignorePatterns reads glob patterns from KickignoreFilename,
+// skipping blank lines and # comments.
+//
+// Yields no patterns when the file is missing.
+func (o Config) kickignorePatterns() ([]string, error) {
+	f, err := os.Open(filepath.Join(o.Dir, KickignoreFilename))
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if closeErr := f.Close(); closeErr != nil && o.Debug {
+			log.Println(closeErr)
+		}
+	}()
+
+	var patterns []string
+	scanner := bufio.NewScanner(f)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		patterns = append(patterns, line)
+	}
+
+	return patterns, scanner.Err()
+}
+
+// stageRules limit which changed paths kick stages.
+type stageRules struct {
+	// include lists globs that paths must match, when nonempty.
+	include []string
+
+	// exclude lists globs that paths must not match.
+	exclude []string
+
+	// nonce denotes the nonce file, which is always staged.
+	nonce string
+
+	// messageFile denotes the MessageModeFile message file, which is never staged.
+	messageFile string
+}
+
+// stageRules collects Include, Exclude, and KickignoreFilename patterns,
+// along with the nonce and message files.
+func (o Config) stageRules() (stageRules, error) {
+	kickignore, err := o.kickignorePatterns()
+
+	if err != nil {
+		return stageRules{}, err
+	}
+
+	rules := stageRules{
+		include:     o.Include,
+		exclude:     append(append([]string{}, o.Exclude...), kickignore...),
+		messageFile: o.messageFileRelPath(),
+	}
+
+	if o.Nonce {
+		if rules.nonce, err = o.noncePath(); err != nil {
+			return stageRules{}, err
+		}
+	}
+
+	return rules, nil
+}
+
+// active reports whether the rules limit staging at all.
+func (o stageRules) active() bool {
+	return len(o.include) != 0 || len(o.exclude) != 0 || o.messageFile != ""
+}
+
+// selects reports whether kick stages a path.
+func (o stageRules) selects(pth string) bool {
+	if o.messageFile != "" && pth == o.messageFile {
+		return false
+	}
+
+	if o.nonce != "" && pth == o.nonce {
+		return true
+	}
+
+	if len(o.include) != 0 && !matchAnyPath(o.include, pth) {
+		return false
+	}
+
+	return !matchAnyPath(o.exclude, pth)
+}
+
+// stagePlan partitions changed paths into those kick stages,
+// and previously staged paths that the rules exclude.
+func (o Config) stagePlan(rules stageRules) ([]string, []string, error) {
+	entries, err := o.status()
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var selected, unstage []string
+
+	for _, entry := range entries {
+		staged := entry.Index != ' ' && entry.Index != '?'
+		paths := []string{entry.Path}
+
+		if entry.OrigPath != "" {
+			paths = append(paths, entry.OrigPath)
+		}
+
+		for _, pth := range paths {
+			switch {
+			case rules.selects(pth):
+				selected = append(selected, pth)
+			case staged:
+				unstage = append(unstage, pth)
+			}
+		}
+	}
+
+	return selected, unstage, nil
+}
+
+// previewChanges predicts the changes that staging would yield, per the rules,
+// without changing the index.
+func (o Config) previewChanges(rules stageRules) ([]statusEntry, error) {
+	entries, err := o.status()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []statusEntry
+
+	for _, entry := range entries {
+		if rules.selects(entry.Path) {
+			changes = append(changes, entry)
+		}
+	}
+
+	return changes, nil
+}
+
+// selectedPaths lists changed paths that kick stages.
+func (o Config) selectedPaths() ([]string, error) {
+	rules, err := o.stageRules()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !rules.active() {
+		return o.changedPaths()
+	}
+
+	selected, _, err := o.stagePlan(rules)
+	return selected, err
+}
+
+// unstageArgs denotes git arguments for unstaging excluded paths, read from standard input.
+func unstageArgs() []string {
+	return append([]string{"reset", "--quiet"}, pathspecArgs...)
+}
+
+// selectiveStageArgs denotes git arguments for staging selected paths, read from standard input.
+func selectiveStageArgs() []string {
+	return append([]string{"add", "--all"}, pathspecArgs...)
+}
@@ -0,0 +1,116 @@
+package kick
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestStageRulesSelects(t *testing.T) {
+	rules := stageRules{
+		include:     []string{"docs/", "*.md"},
+		exclude:     []string{"*.tmp", "/docs/private/"},
+		nonce:       ".kick",
+		messageFile: "MESSAGE.md",
+	}
+
+	for _, tc := range []struct {
+		pth      string
+		expected bool
+	}{
+		{"README.md", true},
+		{"docs/setup.txt", true},
+		{"src/main.go", false},
+		{"docs/draft.tmp", false},
+		{"docs/private/notes.md", false},
+		{".kick", true},
+		{"MESSAGE.md", false},
+	} {
+		t.Run(tc.pth, func(t *testing.T) {
+			if selected := rules.selects(tc.pth); selected != tc.expected {
+				t.Errorf("got %v, expected %v", selected, tc.expected)
+			}
+		})
+	}
+}
+
+func TestStageRulesActive(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		rules    stageRules
+		expected bool
+	}{
+		{"none", stageRules{}, false},
+		{"nonce only", stageRules{nonce: ".kick"}, false},
+		{"include", stageRules{include: []string{"*.md"}}, true},
+		{"exclude", stageRules{exclude: []string{"*.tmp"}}, true},
+		{"message file", stageRules{messageFile: "MESSAGE"}, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if active := tc.rules.active(); active != tc.expected {
+				t.Errorf("got %v, expected %v", active, tc.expected)
+			}
+		})
+	}
+}
+
+func TestStagePlan(t *testing.T) {
+	config, _ := fakeConfig(t, fakeResponse{
+		prefix: "status --porcelain=v1",
+		stdout: " M docs/a.md\x00?? src/b.go\x00M  src/staged.go\x00R  docs/new.md\x00src/old.md\x00?? docs/draft.tmp\x00",
+	})
+	writeWorkingFiles(t, config.Dir, map[string]string{KickignoreFilename: "# scratch files\n\n*.tmp\n"})
+	config.Include = []string{"docs/"}
+	rules, err := config.stageRules()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	selected, unstage, err := config.stagePlan(rules)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := []string{"docs/a.md", "docs/new.md"}; !slices.Equal(selected, expected) {
+		t.Errorf("selected: got %q, expected %q", selected, expected)
+	}
+
+	if expected := []string{"src/staged.go", "src/old.md"}; !slices.Equal(unstage, expected) {
+		t.Errorf("unstage: got %q, expected %q", unstage, expected)
+	}
+}
+
+func TestStage(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		include   []string
+		called    []string
+		notCalled []string
+	}{
+		{"everything", nil, []string{"add ."}, []string{"add --all", "reset"}},
+		{"selected", []string{"docs/"}, []string{"reset --quiet --pathspec-from-file=-", "add --all --pathspec-from-file=-"}, []string{"add ."}},
+		{"nothing selected", []string{"notes/"}, []string{"reset --quiet"}, []string{"add"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			config, runner := fakeConfig(t, fakeResponse{prefix: "status --porcelain=v1", stdout: " M docs/a.md\x00M  src/staged.go\x00"})
+			config.Include = tc.include
+
+			if err := config.Stage(); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, prefix := range tc.called {
+				if !runner.called(prefix) {
+					t.Errorf("expected git %s, calls: %q", prefix, runner.calls)
+				}
+			}
+
+			for _, prefix := range tc.notCalled {
+				if runner.called(prefix) {
+					t.Errorf("unexpected git %s, calls: %q", prefix, runner.calls)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package kick
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultWatchDebounce denotes the default settling time for file changes in Watch.
+const DefaultWatchDebounce = 5 * time.Second
+
+// DefaultWatchPullInterval denotes the default idle pull interval in Watch.
+const DefaultWatchPullInterval = 5 * time.Minute
+
+// WatchPollInterval denotes how often Watch inspects the working tree.
+const WatchPollInterval = time.Second
+
+// Refresh pulls any remote changes and tags, without committing or pushing,
+// running the after_pull hook when new commits arrive.
+func (o Config) Refresh() error {
+	if err := o.pull(); err != nil {
+		return err
+	}
+
+	if o.SyncTags {
+		return o.FetchTags()
+	}
+
+	return nil
+}
+
+// fingerprint summarizes the working tree state visible to git.
+//
+// The fingerprint covers changed paths along with their sizes and modification times,
+// so that repeated edits to an already modified file register as changes.
+// The .git directory and ignored paths are excluded.
+func (o Config) fingerprint() (string, error) {
+	paths, err := o.changedPaths()
+
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+
+	for _, pth := range paths {
+		b.WriteString(pth)
+
+		if fi, statErr := os.Stat(filepath.Join(o.Dir, pth)); statErr == nil {
+			fmt.Fprintf(&b, " %d %d", fi.Size(), fi.ModTime().UnixNano())
+		}
+
+		b.WriteString("\n")
+	}
+
+	return b.String(), nil
+}
+
+// Watch monitors the working tree until the context ends,
+// kicking after changes settle for WatchDebounce,
+// and pulling every WatchPullInterval while idle.
+//
+// Failed cycles are logged, and watching continues.
+// Cancellation takes effect once any kick or pull in progress completes.
+// Git, hook, and message commands are shielded from interrupt signals, so that shutdown never stops them halfway through.
+// Shielded commands cannot prompt on the terminal, so Watch requires a CommitMessage.
+func (o Config) Watch(ctx context.Context) error {
+	if o.Runner == nil {
+		o.Runner = ExecGitRunner{Debug: o.Debug, Isolate: true}
+	}
+
+	o.isolate = true
+
+	if err := o.Validate(); err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(o.CommitMessage) == "" {
+		return UsageError{Err: errors.New("watch mode requires a commit message, as git cannot prompt for one")}
+	}
+
+	if err := o.ResolveDir(); err != nil {
+		return err
+	}
+
+	debounce := o.WatchDebounce
+
+	if debounce <= 0 {
+		debounce = DefaultWatchDebounce
+	}
+
+	pullInterval := o.WatchPullInterval
+
+	if pullInterval <= 0 {
+		pullInterval = DefaultWatchPullInterval
+	}
+
+	last, err := o.fingerprint()
+
+	if err != nil {
+		return err
+	}
+
+	var lastChange time.Time
+	pending := false
+	lastSync := time.Now()
+	ticker := time.NewTicker(WatchPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		var current string
+		current, err = o.fingerprint()
+
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		if current != last {
+			last = current
+			lastChange = time.Now()
+			pending = true
+		}
+
+		switch {
+		case pending && time.Since(lastChange) >= debounce:
+			if o.Debug {
+				log.Println("watch: kicking")
+			}
+
+			if err = o.Kick(); err != nil {
+				log.Println(err)
+			}
+
+			// Mass change overrides cover a single kick.
+			o.AllowMassChange = false
+			pending = false
+			lastSync = time.Now()
+		case !pending && time.Since(lastSync) >= pullInterval:
+			if o.Debug {
+				log.Println("watch: refreshing")
+			}
+
+			if err = o.Refresh(); err != nil {
+				log.Println(err)
+			}
+
+			lastSync = time.Now()
+		default:
+			continue
+		}
+
+		if current, err = o.fingerprint(); err == nil {
+			last = current
+		}
+	}
+}
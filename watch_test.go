@@ -0,0 +1,119 @@
+package kick
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncRunner guards a fake runner for use across goroutines.
+type syncRunner struct {
+	// mu guards runner.
+	mu sync.Mutex
+
+	// runner answers git commands.
+	runner *fakeRunner
+}
+
+// Run answers a git command.
+func (o *syncRunner) Run(command GitCommand) (GitResult, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.runner.Run(command)
+}
+
+// called reports whether any invocation began with a prefix.
+func (o *syncRunner) called(prefix string) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.runner.called(prefix)
+}
+
+func TestWatchRejectsInvalidSettings(t *testing.T) {
+	config, runner := fakeConfig(t)
+	config.ConflictPolicy = "panic"
+	err := config.Watch(context.Background())
+	var usageErr UsageError
+
+	if !errors.As(err, &usageErr) {
+		t.Errorf("expected UsageError, got %v", err)
+	}
+
+	if len(runner.calls) != 0 {
+		t.Errorf("unexpected git calls: %q", runner.calls)
+	}
+}
+
+func TestWatchRejectsBlankCommitMessage(t *testing.T) {
+	for _, mode := range []string{MessageModeTemplate, MessageModeAuto} {
+		t.Run(mode, func(t *testing.T) {
+			config, runner := fakeConfig(t)
+			config.MessageMode = mode
+			config.CommitMessage = " "
+			err := config.Watch(context.Background())
+			var usageErr UsageError
+
+			if !errors.As(err, &usageErr) {
+				t.Errorf("expected UsageError, got %v", err)
+			}
+
+			if len(runner.calls) != 0 {
+				t.Errorf("unexpected git calls: %q", runner.calls)
+			}
+		})
+	}
+}
+
+func TestWatchStopsWhenCancelled(t *testing.T) {
+	config, runner := fakeConfig(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := config.Watch(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if runner.called("push") {
+		t.Errorf("unexpected kick, calls: %q", runner.calls)
+	}
+}
+
+func TestWatchKicksAfterChanges(t *testing.T) {
+	config, fake := fakeConfig(t, fakeResponse{prefix: "status --porcelain=v1", stdout: "?? a.txt\x00"})
+	runner := &syncRunner{runner: fake}
+	config.Runner = runner
+	config.WatchDebounce = time.Nanosecond
+	pth := filepath.Join(config.Dir, "a.txt")
+	writeWorkingFiles(t, config.Dir, map[string]string{"a.txt": "a"})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+
+	go func() { done <- config.Watch(ctx) }()
+
+	// Let Watch record the initial fingerprint before editing.
+	time.Sleep(WatchPollInterval / 2)
+
+	if err := os.WriteFile(pth, []byte("edited"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * WatchPollInterval)
+
+	for !runner.called("push --all") && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	if !runner.called("push --all") {
+		t.Errorf("expected a kick after changes, calls: %q", fake.calls)
+	}
+}
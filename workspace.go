@@ -0,0 +1,158 @@
+package kick
+
+import (
+	"bufio"
+	"bytes"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultParallelism denotes the default number of repositories kicked concurrently.
+const DefaultParallelism = 4
+
+// RepositoryResult reports the outcome of kicking one workspace repository.
+type RepositoryResult struct {
+	// Dir denotes the repository directory.
+	Dir string
+
+	// Err denotes any failure.
+	Err error
+
+	// Duration measures the time spent kicking the repository.
+	Duration time.Duration
+}
+
+// DiscoverRepositories finds git repositories at or beneath a root directory.
+//
+// Discovery does not descend into repositories, so nested repositories and submodules are skipped.
+func DiscoverRepositories(root string) ([]string, error) {
+	var repositories []string
+
+	err := filepath.WalkDir(root, func(pth string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !entry.IsDir() {
+			return nil
+		}
+
+		if _, statErr := os.Stat(filepath.Join(pth, ".git")); statErr == nil {
+			repositories = append(repositories, pth)
+			return filepath.SkipDir
+		}
+
+		return nil
+	})
+
+	return repositories, err
+}
+
+// ReadManifest loads repository directories from a manifest file.
+//
+// Manifests list one directory per line.
+// Blank lines and lines beginning with # are ignored.
+// Relative directories resolve against the manifest's own directory.
+func ReadManifest(pth string) ([]string, error) {
+	manifestBytes, err := os.ReadFile(pth)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var repositories []string
+	base := filepath.Dir(pth)
+	scanner := bufio.NewScanner(bytes.NewReader(manifestBytes))
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if !filepath.IsAbs(line) {
+			line = filepath.Join(base, line)
+		}
+
+		repositories = append(repositories, line)
+	}
+
+	return repositories, scanner.Err()
+}
+
+// ConfigLoader prepares the configuration for a repository directory,
+// such as by layering the repository's own ConfigFilename beneath environment variables and flags.
+type ConfigLoader func(dir string) (Config, error)
+
+// KickWorkspace kicks each repository concurrently, configured by load,
+// with at most parallelism repositories in flight.
+//
+// Failures are reported per repository, without interrupting the others.
+// Results follow the order of the given repositories.
+func KickWorkspace(repositories []string, parallelism int, load ConfigLoader) []RepositoryResult {
+	if parallelism < 1 {
+		parallelism = DefaultParallelism
+	}
+
+	results := make([]RepositoryResult, len(repositories))
+	semaphore := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+
+	for i, repository := range repositories {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			start := time.Now()
+			config, err := load(repository)
+
+			if err == nil {
+				err = config.Kick()
+			}
+
+			if err != nil && config.Debug {
+				log.Printf("%s: %v\n", repository, err)
+			}
+
+			results[i] = RepositoryResult{Dir: repository, Err: err, Duration: time.Since(start)}
+		}()
+	}
+
+	wg.Wait()
+	return results
+}
+
+// WorkspaceExitCode aggregates repository results into a process exit status.
+//
+// Yields ExitSuccess when every repository succeeds,
+// the shared exit code when all failures agree,
+// and ExitFailure otherwise.
+func WorkspaceExitCode(results []RepositoryResult) int {
+	code := ExitSuccess
+
+	for _, result := range results {
+		if result.Err == nil {
+			continue
+		}
+
+		resultCode := ExitCode(result.Err)
+
+		if code != ExitSuccess && code != resultCode {
+			return ExitFailure
+		}
+
+		code = resultCode
+	}
+
+	return code
+}
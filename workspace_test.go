@@ -0,0 +1,170 @@
+package kick
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// concurrencyRunner tracks the peak number of concurrent git commands across repositories.
+type concurrencyRunner struct {
+	// runner answers git commands.
+	runner *fakeRunner
+
+	// mu guards runner.
+	mu *sync.Mutex
+
+	// inFlight counts running commands.
+	inFlight *atomic.Int32
+
+	// peak records the most commands running at once.
+	peak *atomic.Int32
+}
+
+// Run answers a git command after a short delay, recording concurrency.
+func (o concurrencyRunner) Run(command GitCommand) (GitResult, error) {
+	n := o.inFlight.Add(1)
+	defer o.inFlight.Add(-1)
+
+	for {
+		peak := o.peak.Load()
+
+		if n <= peak || o.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+
+	time.Sleep(time.Millisecond)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.runner.Run(command)
+}
+
+func TestKickWorkspace(t *testing.T) {
+	var mu sync.Mutex
+	var inFlight, peak atomic.Int32
+	loadErr := UsageError{Err: errors.New("invalid")}
+	configs := make(map[string]Config)
+	var repositories []string
+
+	for _, name := range []string{"a", "b", "c", "d", "e", "f"} {
+		config, runner := fakeConfig(t)
+		config.Runner = concurrencyRunner{runner: runner, mu: &mu, inFlight: &inFlight, peak: &peak}
+		configs[name] = config
+		repositories = append(repositories, name)
+	}
+
+	repositories = append(repositories, "invalid")
+	results := KickWorkspace(repositories, 2, func(dir string) (Config, error) {
+		config, ok := configs[dir]
+
+		if !ok {
+			return Config{}, loadErr
+		}
+
+		return config, nil
+	})
+
+	if len(results) != len(repositories) {
+		t.Fatalf("got %d results, expected %d", len(results), len(repositories))
+	}
+
+	for i, result := range results {
+		if result.Dir != repositories[i] {
+			t.Errorf("result %d: got %s, expected %s", i, result.Dir, repositories[i])
+		}
+
+		var expected error
+
+		if result.Dir == "invalid" {
+			expected = loadErr
+		}
+
+		if !errors.Is(result.Err, expected) {
+			t.Errorf("%s: got %v, expected %v", result.Dir, result.Err, expected)
+		}
+	}
+
+	if p := peak.Load(); p > 2 {
+		t.Errorf("ran %d repositories at once, expected at most 2", p)
+	}
+
+	if code := WorkspaceExitCode(results); code != ExitUsage {
+		t.Errorf("got exit code %d, expected %d", code, ExitUsage)
+	}
+}
+
+func TestDiscoverRepositories(t *testing.T) {
+	root := t.TempDir()
+
+	for _, dir := range []string{"a/.git", "b/c/.git", "a/nested/.git", "d"} {
+		if err := os.MkdirAll(filepath.Join(root, filepath.FromSlash(dir)), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	repositories, err := DiscoverRepositories(root)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{filepath.Join(root, "a"), filepath.Join(root, "b", "c")}
+
+	if !slices.Equal(repositories, expected) {
+		t.Errorf("got %q, expected %q", repositories, expected)
+	}
+}
+
+func TestReadManifest(t *testing.T) {
+	dir := t.TempDir()
+	pth := filepath.Join(dir, "repos.txt")
+	abs := filepath.Join(t.TempDir(), "abs")
+
+	if err := os.WriteFile(pth, []byte("# repositories\n\nrel\n  "+abs+"  \n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	repositories, err := ReadManifest(pth)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := []string{filepath.Join(dir, "rel"), abs}; !slices.Equal(repositories, expected) {
+		t.Errorf("got %q, expected %q", repositories, expected)
+	}
+}
+
+func TestWorkspaceExitCode(t *testing.T) {
+	stageErr := GitError{Step: StepStage}
+	commitErr := GitError{Step: StepCommit}
+
+	for _, tc := range []struct {
+		name     string
+		errs     []error
+		expected int
+	}{
+		{"all succeed", []error{nil, nil}, ExitSuccess},
+		{"one fails", []error{nil, stageErr}, ExitStage},
+		{"same failures", []error{stageErr, nil, stageErr}, ExitStage},
+		{"mixed failures", []error{stageErr, commitErr}, ExitFailure},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var results []RepositoryResult
+
+			for _, err := range tc.errs {
+				results = append(results, RepositoryResult{Err: err})
+			}
+
+			if code := WorkspaceExitCode(results); code != tc.expected {
+				t.Errorf("got %d, expected %d", code, tc.expected)
+			}
+		})
+	}
+}